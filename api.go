@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"ytdlp/services/api"
+	"ytdlp/services/history"
+	"ytdlp/services/job"
+	ytdlp "ytdlp/services/yt-dlp"
+	"ytdlp/utils/emit"
+)
+
+type ControlApiInfo struct {
+	Url   string `json:"url"`
+	Token string `json:"token"`
+}
+
+// StartControlApi serves the local control api on 127.0.0.1, port 0 picks a
+// free port.
+func (a *App) StartControlApi(port int) (ControlApiInfo, error) {
+	url, err := a.controlApi.Start(port)
+	if err != nil {
+		return ControlApiInfo{}, err
+	}
+	token, errToken := api.LoadToken()
+	if errToken != nil {
+		return ControlApiInfo{}, errToken
+	}
+	return ControlApiInfo{Url: url, Token: token}, nil
+}
+
+func (a *App) StopControlApi() error {
+	return a.controlApi.Stop()
+}
+
+// ResetControlApiToken replaces the api token, clients using the old one are
+// rejected right away.
+func (a *App) ResetControlApiToken() (string, error) {
+	return a.controlApi.ResetToken()
+}
+
+func (a *App) ProbeUrl(url string) (ytdlp.ProbeInfo, error) {
+	return ytdlp.NewYtDlp(&a.ctx, url, ytdlp.SplitState{}, emit.NewEmitDownload(&a.ctx)).Probe()
+}
+
+func (a *App) startControlApi() {
+	a.controlApi = api.NewServer(&a.ctx, &controlBackend{app: a}, a.events)
+}
+
+// controlBackend adapts the app to the api, it is kept apart from App so its
+// methods are not bound to the window.
+type controlBackend struct {
+	app *App
+}
+
+func (b *controlBackend) Enqueue(request api.EnqueueRequest) (job.Task, error) {
+	url := strings.TrimSpace(request.Url)
+	if url == "" {
+		return job.Task{}, errors.New("url is required")
+	}
+	if request.Direct {
+		if err := validDirectUrl(url); err != nil {
+			return job.Task{}, err
+		}
+		if err := validSha256(request.Sha256); err != nil {
+			return job.Task{}, err
+		}
+		return b.app.queue.Enqueue(job.Task{
+			Url:    url,
+			Direct: true,
+			Sha256: strings.TrimSpace(request.Sha256),
+			Source: "api",
+		}), nil
+	}
+	if _, err := ytdlp.GetPreset(request.Preset); err != nil {
+		return job.Task{}, err
+	}
+	options := ytdlp.DownloadOptions{Preset: request.Preset}
+	if request.Cookies != "" {
+		cookiesFile, err := ytdlp.WriteCookiesFile(request.Cookies)
+		if err != nil {
+			return job.Task{}, err
+		}
+		options.CookiesFile = cookiesFile
+	}
+	return b.app.queue.Enqueue(job.Task{
+		Url:     url,
+		Split:   request.Split,
+		Options: options,
+		Source:  "api",
+	}), nil
+}
+
+func (b *controlBackend) List() api.JobList {
+	return api.JobList{
+		Tasks: b.app.queue.List(),
+		Jobs:  b.app.jobs.List(),
+	}
+}
+
+func (b *controlBackend) Cancel(id string) error {
+	for _, task := range b.app.queue.List() {
+		if task.ID == id {
+			return b.app.queue.Cancel(id)
+		}
+	}
+	return b.app.jobs.Cancel(id)
+}
+
+func (b *controlBackend) Probe(ctx *context.Context, url string) (ytdlp.ProbeInfo, error) {
+	return ytdlp.NewYtDlp(ctx, url, ytdlp.SplitState{}, emit.NewEmitDownload(ctx)).Probe()
+}
+
+func (b *controlBackend) History() ([]history.Entry, error) {
+	return history.Default().List()
+}
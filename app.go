@@ -2,26 +2,74 @@ package main
 
 import (
 	"context"
+	"sync"
+	"ytdlp/helpers/logrus"
+	"ytdlp/services/api"
+	"ytdlp/services/instance"
+	"ytdlp/services/job"
+	"ytdlp/services/podcast"
+	"ytdlp/services/scheduler"
+	"ytdlp/services/subscription"
+	ytdlp "ytdlp/services/yt-dlp"
+	"ytdlp/utils/emit"
 )
 
-type ContextState struct {
-	Ctx    *context.Context
-	Cancel *context.CancelFunc
-}
-
 // App struct
 type App struct {
-	ctx         context.Context
-	ctxDownload ContextState
+	ctx           context.Context
+	jobs          *job.Manager
+	queue         *job.Queue
+	scheduler     *scheduler.Scheduler
+	subscriptions *subscription.Poller
+	podcasts      *podcast.Server
+	controlApi    *api.Server
+	instance      *instance.Instance
+	// events mirrors every event to the control api streams
+	events *api.Broadcaster
+
+	transcriptsMu   sync.Mutex
+	transcripts     map[string]cachedTranscript
+	transcriptCalls map[string]*transcriptCall
+
+	replaysMu sync.Mutex
+	replays   map[string]*ytdlp.ReplayBuffer
 }
 
 // NewApp creates a new App application struct
-func NewApp() *App {
-	return &App{}
+func NewApp(single *instance.Instance) *App {
+	return &App{
+		instance:        single,
+		jobs:            job.NewManager(),
+		events:          api.NewBroadcaster(),
+		transcripts:     map[string]cachedTranscript{},
+		transcriptCalls: map[string]*transcriptCall{},
+		replays:         map[string]*ytdlp.ReplayBuffer{},
+	}
 }
 
 // startup is called when the app starts. The context is saved
 // so we can call the runtime methods
 func (a *App) startup(ctx context.Context) {
-	a.ctx = ctx
+	a.ctx = emit.WithSink(ctx, emit.NewMultiSink(emit.NewWailsSink(ctx), a.events))
+	a.startQueue()
+	a.startScheduler()
+	a.startSubscriptions()
+	a.startPodcasts()
+	a.startControlApi()
+	a.startSettings()
+	a.instance.Serve(a.openLinks)
+}
+
+// shutdown stops every running job so no yt-dlp or ffmpeg process outlives the window
+func (a *App) shutdown(ctx context.Context) {
+	a.jobs.CancelAll()
+	if err := a.podcasts.Stop(); err != nil {
+		logrus.LogrusLoggerWithContext(&a.ctx).Error(err.Error())
+	}
+	if err := a.controlApi.Stop(); err != nil {
+		logrus.LogrusLoggerWithContext(&a.ctx).Error(err.Error())
+	}
+	if err := a.instance.Close(); err != nil {
+		logrus.LogrusLoggerWithContext(&a.ctx).Error(err.Error())
+	}
 }
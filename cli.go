@@ -0,0 +1,433 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+	"ytdlp/helpers/logrus"
+	"ytdlp/services/history"
+	"ytdlp/services/instance"
+	"ytdlp/services/nativehost"
+	ytdlp "ytdlp/services/yt-dlp"
+	"ytdlp/utils"
+	"ytdlp/utils/diagnose"
+	"ytdlp/utils/emit"
+	"ytdlp/utils/resource"
+	"ytdlp/utils/settings"
+	"ytdlp/utils/setup"
+)
+
+const cliUsage = `Usage: ytdlp [--home <dir>] cli <command> [flags]
+
+Commands:
+  download <url>   download a video or a part of it
+  setup            install ffmpeg and yt-dlp
+  update           update yt-dlp from its release channel
+  history          list finished downloads
+  diagnose         check ffmpeg, yt-dlp and the disks
+  native-host      register the browser extension host
+  register-scheme  open ytdlp:// links with this binary
+  migrate-home     move the data folder, e.g. to a portable copy
+
+Run "ytdlp cli <command> -h" for the flags of a command. The data folder is
+~/.ytdlp unless --home or YTDLP_HOME is set, or ytdlp.portable sits next to
+the executable.
+`
+
+// runCli runs the headless entry point sharing the download core with the
+// window, it returns the process exit code.
+func runCli(args []string) int {
+	attachConsole()
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, cliUsage)
+		return 2
+	}
+	switch args[0] {
+	case "download":
+		return cliDownload(args[1:])
+	case "setup":
+		return cliSetup(args[1:])
+	case "update":
+		return cliUpdate(args[1:])
+	case "history":
+		return cliHistory(args[1:])
+	case "diagnose":
+		return cliDiagnose(args[1:])
+	case "native-host":
+		return cliNativeHost(args[1:])
+	case "register-scheme":
+		return cliRegisterScheme()
+	case "migrate-home":
+		return cliMigrateHome(args[1:])
+	case "help", "-h", "--help":
+		fmt.Fprint(os.Stdout, cliUsage)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", args[0], cliUsage)
+		return 2
+	}
+}
+
+func cliDownload(args []string) int {
+	fs := flag.NewFlagSet("download", flag.ContinueOnError)
+	start := fs.String("start", "", "section start, e.g. 00:01:30")
+	end := fs.String("end", "", "section end, e.g. 00:02:00")
+	preset := fs.String("preset", settings.Current().Preset, "quality preset")
+	var output cliOutput
+	output.register(fs)
+	skipSetup := fs.Bool("no-setup", false, "do not install missing resources")
+	direct := fs.Bool("direct", false, "fetch the url as a plain file instead of through yt-dlp")
+	sha256 := fs.String("sha256", "", "expected sha256 of a --direct download")
+	positional, errParse := parseInterspersed(fs, args)
+	if errParse != nil {
+		return 2
+	}
+	if len(positional) != 1 {
+		fmt.Fprintln(os.Stderr, "download needs exactly one url")
+		return 2
+	}
+	if _, err := ytdlp.GetPreset(*preset); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 2
+	}
+	url := positional[0]
+	split := ytdlp.SplitState{Start: *start, End: *end}
+
+	ctx, stop, errOutput := output.context()
+	if errOutput != nil {
+		fmt.Fprintln(os.Stderr, errOutput.Error())
+		return 2
+	}
+	defer stop()
+	if *direct {
+		return cliDownloadDirect(&ctx, url, *sha256)
+	}
+	if !*skipSetup {
+		if _, err := setup.NewSetup(&ctx).Install(); err != nil {
+			emit.Message(&ctx, emit.MessageStatusError, err.Error())
+			return 1
+		}
+	}
+	startedAt := time.Now()
+	emitDownload := emit.NewEmitDownload(&ctx)
+	emitDownload.Start()
+	ytd := ytdlp.NewYtDlp(&ctx, url, split, emitDownload)
+	ytd.SetOptions(ytdlp.DownloadOptions{Preset: *preset})
+	err := ytd.Download()
+	emitDownload.Stop()
+	addHistory(&ctx, "cli", url, split, *preset, ytd.Files(), startedAt, err)
+	if err != nil {
+		emit.Message(&ctx, emit.MessageStatusError, err.Error())
+		return 1
+	}
+	for _, file := range ytd.Files() {
+		emit.Message(&ctx, emit.MessageStatusSuccess, fmt.Sprintf("Saved %s", file))
+	}
+	return 0
+}
+
+func cliDownloadDirect(ctx *context.Context, url string, sha256 string) int {
+	startedAt := time.Now()
+	emitDownload := emit.NewEmitDownload(ctx)
+	emitDownload.Start()
+	file, err := downloadDirect(ctx, url, sha256, emitDownload)
+	emitDownload.Stop()
+	addHistory(ctx, "cli", url, ytdlp.SplitState{}, "", fileList(file), startedAt, err)
+	if err != nil {
+		emit.Message(ctx, emit.MessageStatusError, err.Error())
+		return 1
+	}
+	emit.Message(ctx, emit.MessageStatusSuccess, fmt.Sprintf("Saved %s", file))
+	return 0
+}
+
+func cliSetup(args []string) int {
+	fs := flag.NewFlagSet("setup", flag.ContinueOnError)
+	bundle := fs.String("bundle", "", "install from a resource bundle zip instead of downloading")
+	export := fs.String("export", "", "save the installed resources as a bundle zip")
+	only := fs.String("resource", "", "set up only this resource, e.g. yt-dlp")
+	var output cliOutput
+	output.register(fs)
+	if _, err := parseInterspersed(fs, args); err != nil {
+		return 2
+	}
+	ctx, stop, errOutput := output.context()
+	if errOutput != nil {
+		fmt.Fprintln(os.Stderr, errOutput.Error())
+		return 2
+	}
+	defer stop()
+	if *export != "" {
+		manifest, err := setup.ExportBundle(&ctx, *export)
+		if err != nil {
+			emit.Message(&ctx, emit.MessageStatusError, err.Error())
+			return 1
+		}
+		emit.Message(&ctx, emit.MessageStatusSuccess, fmt.Sprintf("Saved %s to %s", manifest.Summary(), *export))
+		return 0
+	}
+	var errInstall error
+	switch {
+	case *bundle != "":
+		_, errInstall = setup.InstallBundle(&ctx, *bundle)
+	case *only != "":
+		_, errInstall = setup.NewSetup(&ctx).InstallResource(*only)
+	default:
+		_, errInstall = setup.NewSetup(&ctx).Install()
+	}
+	if errInstall != nil {
+		emit.Message(&ctx, emit.MessageStatusError, errInstall.Error())
+		return 1
+	}
+	for _, resolution := range resource.ResolveAll() {
+		emit.Message(&ctx, emit.MessageStatusInfo, fmt.Sprintf("%s: %s (%s)", resolution.Title, resolution.Path, resolution.Source))
+	}
+	emit.Message(&ctx, emit.MessageStatusSuccess, "Resource is ready")
+	return 0
+}
+
+func cliUpdate(args []string) int {
+	fs := flag.NewFlagSet("update", flag.ContinueOnError)
+	check := fs.Bool("check", false, "only report whether an update is available")
+	rollback := fs.Bool("rollback", false, "go back to the version before the last update")
+	var output cliOutput
+	output.register(fs)
+	if _, err := parseInterspersed(fs, args); err != nil {
+		return 2
+	}
+	ctx, stop, errOutput := output.context()
+	if errOutput != nil {
+		fmt.Fprintln(os.Stderr, errOutput.Error())
+		return 2
+	}
+	defer stop()
+	if !*check && instance.IsRunning(utils.GetHomeDir()) {
+		emit.Message(&ctx, emit.MessageStatusError, "ytdlp is open and may be running yt-dlp, update from the app or close it first")
+		return 1
+	}
+	var info setup.UpdateInfo
+	var err error
+	switch {
+	case *rollback:
+		info, err = setup.Rollback(&ctx, resource.YtDlp.Key)
+	case *check:
+		info, err = setup.CheckUpdate(&ctx, resource.YtDlp.Key)
+	default:
+		info, err = setup.Update(&ctx, resource.YtDlp.Key)
+	}
+	if err != nil {
+		emit.Message(&ctx, emit.MessageStatusError, err.Error())
+		return 1
+	}
+	message := fmt.Sprintf("%s %s (%s channel)", info.Title, info.Current, info.Channel)
+	if *check && info.Available {
+		message = fmt.Sprintf("%s, %s is available", message, info.Latest)
+	}
+	emit.Message(&ctx, emit.MessageStatusSuccess, message)
+	return 0
+}
+
+func cliHistory(args []string) int {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	jsonLines := fs.Bool("json", false, "print entries as json lines")
+	limit := fs.Int("limit", 20, "number of entries, 0 for all")
+	if _, err := parseInterspersed(fs, args); err != nil {
+		return 2
+	}
+	logrus.InitLogrusLoggerWithConsole(nil)
+	entries, err := history.Default().List()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	if *limit > 0 && len(entries) > *limit {
+		entries = entries[:*limit]
+	}
+	for _, entry := range entries {
+		if *jsonLines {
+			line, _ := json.Marshal(entry)
+			fmt.Fprintln(os.Stdout, string(line))
+			continue
+		}
+		section := ""
+		if entry.Split.Start != "" || entry.Split.End != "" {
+			section = fmt.Sprintf(" [%s-%s]", entry.Split.Start, entry.Split.End)
+		}
+		fmt.Fprintf(os.Stdout, "%s  %-8s %s%s %s\n", entry.FinishedAt.Format("2006-01-02 15:04:05"),
+			entry.Status, entry.Url, section, strings.Join(entry.Files, ", "))
+	}
+	return 0
+}
+
+func cliDiagnose(args []string) int {
+	fs := flag.NewFlagSet("diagnose", flag.ContinueOnError)
+	jsonOutput := fs.Bool("json", false, "print the report as json")
+	if _, err := parseInterspersed(fs, args); err != nil {
+		return 2
+	}
+	logrus.InitLogrusLoggerWithConsole(nil)
+	report := diagnose.Run()
+	if *jsonOutput {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Fprintln(os.Stdout, string(data))
+	} else {
+		fmt.Fprintf(os.Stdout, "Home dir: %s (%s)\n", report.HomeDir, report.HomeMode)
+		for _, entry := range report.Resources {
+			detail := entry.Version
+			if entry.Message != "" {
+				detail = entry.Message
+			}
+			fmt.Fprintf(os.Stdout, "%-8s %s: %s (%s) %s\n", entry.Status, entry.Title, entry.Path, entry.Source, detail)
+		}
+		for _, check := range report.Checks {
+			fmt.Fprintf(os.Stdout, "%-8s %s: %s\n", check.Status, check.Name, check.Message)
+		}
+	}
+	if report.Status == diagnose.StatusError {
+		return 1
+	}
+	return 0
+}
+
+func cliRegisterScheme() int {
+	logrus.InitLogrusLoggerWithConsole(nil)
+	if err := instance.RegisterScheme(utils.HomeArgs()); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	fmt.Fprintf(os.Stdout, "%s:// links now open with this binary\n", instance.Scheme)
+	return 0
+}
+
+// cliMigrateHome moves a data folder without opening the log file, which
+// lives inside the folder being moved.
+func cliMigrateHome(args []string) int {
+	fs := flag.NewFlagSet("migrate-home", flag.ContinueOnError)
+	from := fs.String("from", utils.DefaultHomeDir(), "data folder to move")
+	portable := fs.Bool("portable", false, "move next to the executable and turn portable mode on")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	var to string
+	switch {
+	case *portable && len(positional) == 0:
+		if to, err = utils.PortableHomeDir(); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1
+		}
+	case !*portable && len(positional) == 1:
+		to = positional[0]
+	default:
+		fmt.Fprintln(os.Stderr, "usage: ytdlp cli migrate-home [--from <dir>] <dir> | --portable")
+		return 2
+	}
+	if instance.IsRunning(*from) {
+		fmt.Fprintln(os.Stderr, "the app is running with this data folder, close it first")
+		return 1
+	}
+	if err := utils.MigrateHome(*from, to); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	fmt.Fprintf(os.Stdout, "moved %s to %s\n", *from, to)
+	if *portable {
+		if err := utils.EnablePortable(); err != nil {
+			fmt.Fprintf(os.Stderr, "moved, but the portable marker could not be written: %s\n", err.Error())
+			return 1
+		}
+		fmt.Fprintln(os.Stdout, "portable mode is on")
+		return reregister(*from, to, nil)
+	}
+	fmt.Fprintf(os.Stdout, "start the app with --home %q or set %s=%s to use it\n", to, utils.HomeEnv, to)
+	return reregister(*from, to, utils.HomeArgsFor(to))
+}
+
+// reregister points the link handler and the browser native hosts of this
+// binary at the moved home dir, they would still start it on the old one.
+func reregister(from string, to string, homeArgs []string) int {
+	code := 0
+	if instance.SchemeRegistered() {
+		if err := instance.RegisterScheme(homeArgs); err != nil {
+			fmt.Fprintf(os.Stderr, "%s:// links still use the old folder: %s\n", instance.Scheme, err.Error())
+			code = 1
+		} else {
+			fmt.Fprintf(os.Stdout, "%s:// links now use the new folder\n", instance.Scheme)
+		}
+	}
+	updated, err := nativehost.Reinstall(from, to, homeArgs)
+	if len(updated) > 0 {
+		fmt.Fprintf(os.Stdout, "native host of %s now uses the new folder\n", strings.Join(updated, ", "))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "native host still uses the old folder: %s\n", err.Error())
+		code = 1
+	}
+	return code
+}
+
+type cliOutput struct {
+	jsonLines  bool
+	verbose    bool
+	eventsFile string
+}
+
+func (o *cliOutput) register(fs *flag.FlagSet) {
+	fs.BoolVar(&o.jsonLines, "json", false, "print events as json lines")
+	fs.BoolVar(&o.verbose, "verbose", false, "print logs to stderr")
+	fs.StringVar(&o.eventsFile, "events-file", "", "also append events as json lines to this file")
+}
+
+// context routes events to stdout and cancels on Ctrl+C, logs only reach
+// stderr when verbose so stdout stays parseable.
+func (o *cliOutput) context() (context.Context, context.CancelFunc, error) {
+	var console io.Writer
+	if o.verbose {
+		console = os.Stderr
+	}
+	logrus.InitLogrusLoggerWithConsole(console)
+	_ = logrus.SetLevel(settings.Current().LogLevel)
+	var sink emit.Sink = emit.NewTerminalSink(os.Stdout)
+	if o.jsonLines {
+		sink = emit.NewJsonLinesSink(os.Stdout)
+	}
+	closeSink := func() {}
+	if o.eventsFile != "" {
+		fileSink, err := emit.NewJsonLinesFileSink(o.eventsFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		sink = emit.NewMultiSink(sink, fileSink)
+		closeSink = func() {
+			_ = fileSink.Close()
+		}
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	return emit.WithSink(ctx, sink), func() {
+		stop()
+		closeSink()
+	}, nil
+}
+
+// parseInterspersed lets flags follow the positional arguments, like
+// "download <url> --start 10".
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := make([]string, 0)
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// attachConsole reconnects stdout to the calling terminal, the binary is built
+// for the windows gui subsystem and starts without a console otherwise.
+func attachConsole() {
+	if _, err := os.Stdout.Stat(); err == nil {
+		// already redirected to a file or a pipe
+		return
+	}
+	attach := syscall.NewLazyDLL("kernel32.dll").NewProc("AttachConsole")
+	const attachParentProcess = ^uintptr(0)
+	if ok, _, _ := attach.Call(attachParentProcess); ok == 0 {
+		return
+	}
+	if console, err := os.OpenFile("CONOUT$", os.O_WRONLY, 0); err == nil {
+		os.Stdout = console
+		os.Stderr = console
+	}
+}
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"ytdlp/helpers/logrus"
+	"ytdlp/utils/diagnose"
+)
+
+// Diagnose runs the resources and checks the disks, the report is meant for
+// a health panel in the window.
+func (a *App) Diagnose() diagnose.Report {
+	report := diagnose.Run()
+	logrus.LogrusLoggerWithContext(&a.ctx).Infof("Diagnose: %s", report.Status)
+	return report
+}
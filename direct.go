@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"ytdlp/helpers/logrus"
+	"ytdlp/services/job"
+	ytdlp "ytdlp/services/yt-dlp"
+	"ytdlp/utils"
+	"ytdlp/utils/emit"
+	"ytdlp/utils/fetch"
+	"ytdlp/utils/settings"
+)
+
+// EnqueueFile queues a link to a plain file, e.g. an mp4 on a web server,
+// it is fetched with the segmented downloader instead of yt-dlp. The file is
+// checked against sha256 when one is given.
+func (a *App) EnqueueFile(fileUrl string, sha256 string) (job.Task, error) {
+	if err := validDirectUrl(fileUrl); err != nil {
+		return job.Task{}, err
+	}
+	if err := validSha256(sha256); err != nil {
+		return job.Task{}, err
+	}
+	return a.queue.Enqueue(job.Task{
+		Url:    strings.TrimSpace(fileUrl),
+		Direct: true,
+		Sha256: strings.TrimSpace(sha256),
+		Source: "app",
+	}), nil
+}
+
+func GetDirectDir() string {
+	return filepath.Join(settings.Current().OutputDir, "direct")
+}
+
+// downloadDirect saves fileUrl into the direct dir and returns the file, an
+// interrupted download resumes when the same url is queued again. A file
+// that does not match sha256, when given, is deleted.
+func downloadDirect(ctx *context.Context, fileUrl string, sha256 string, emitDownload emit.EmitDownload) (string, error) {
+	if err := validDirectUrl(fileUrl); err != nil {
+		return "", err
+	}
+	if err := validSha256(sha256); err != nil {
+		return "", err
+	}
+	if err := utils.CheckOrCreateDir(GetDirectDir()); err != nil {
+		return "", err
+	}
+	filePath := directFilePath(fileUrl)
+	options := fetch.DefaultOptions()
+	options.Sha256 = strings.TrimSpace(sha256)
+	options.OnProgress = func(progress fetch.Progress) {
+		emitDownload.Progress(emit.DownloadStatusDownload, ytdlp.DownloadProgress{
+			Percent:         progress.Percent(),
+			DownloadedBytes: progress.Downloaded,
+			TotalBytes:      progress.Total,
+			Speed:           progress.Speed,
+			Eta:             int64(progress.Eta.Seconds()),
+		})
+	}
+	if err := fetch.NewDownloader(ctx, fileUrl, filePath, options).Download(); err != nil {
+		return "", err
+	}
+	logrus.LogrusLoggerWithContext(ctx).Infof("Saved %s", filePath)
+	return filePath, nil
+}
+
+func validDirectUrl(fileUrl string) error {
+	parsed, err := url.Parse(strings.TrimSpace(fileUrl))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("a file download needs an http(s) url")
+	}
+	return nil
+}
+
+// validSha256 accepts an empty value or 64 hex characters.
+func validSha256(value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	if decoded, err := hex.DecodeString(value); err != nil || len(decoded) != 32 {
+		return fmt.Errorf("sha256 %q must be 64 hex characters", value)
+	}
+	return nil
+}
+
+// directFilePath names the file after the last part of the url path and
+// numbers it when a finished file or the partial download of another url
+// already has the name, so two files of the same name never share a partial.
+func directFilePath(fileUrl string) string {
+	name := "download"
+	if parsed, err := url.Parse(fileUrl); err == nil {
+		if base := path.Base(parsed.Path); base != "/" && base != "." {
+			name = utils.SanitizeFilename(base)
+		}
+	}
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	filePath := filepath.Join(GetDirectDir(), name)
+	for i := 1; ; i++ {
+		if _, err := os.Stat(filePath); os.IsNotExist(err) {
+			if owner, partial := fetch.PartialUrl(filePath); !partial || owner == fileUrl {
+				return filePath
+			}
+		}
+		filePath = filepath.Join(GetDirectDir(), fmt.Sprintf("%s (%d)%s", stem, i, ext))
+	}
+}
+
+func fileList(file string) []string {
+	if file == "" {
+		return []string{}
+	}
+	return []string{file}
+}
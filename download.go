@@ -2,31 +2,62 @@ package main
 
 import (
 	"context"
-	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"time"
 	"ytdlp/helpers/logrus"
+	"ytdlp/services/history"
+	"ytdlp/services/job"
 	ytdlp "ytdlp/services/yt-dlp"
 	"ytdlp/utils/emit"
 )
 
 func (a *App) StartDownload(url string, split ytdlp.SplitState) error {
-	defer runtime.EventsEmit(a.ctx, emit.DownloadStop)
-	ctx, cancel := context.WithCancel(a.ctx)
-	a.ctxDownload = ContextState{
-		Ctx:    &ctx,
-		Cancel: &cancel,
-	}
-	defer cancel()
+	defer emit.Emit(&a.ctx, emit.DownloadStop)
+	j := a.jobs.Start(a.ctx, job.KindDownload, url)
+	defer a.jobs.Finish(j.ID)
+	ctx := j.Ctx
 	select {
-	case <-ctx.Done():
+	case <-(*ctx).Done():
 		return nil
 	default:
 	}
-	emitDownload := emit.NewEmitDownload(&ctx)
-	ytd := ytdlp.NewYtDlp(&ctx, url, split, emitDownload)
-	if err := ytd.Download(); err != nil {
-		emit.Message(&ctx, emit.MessageStatusError, err.Error())
+	startedAt := time.Now()
+	emitDownload := emit.NewEmitJobDownload(ctx, j.ID)
+	ytd := ytdlp.NewYtDlp(ctx, url, split, emitDownload)
+	err := ytd.Download()
+	addHistory(ctx, "app", url, split, "", ytd.Files(), startedAt, err)
+	if err != nil {
+		emit.Message(ctx, emit.MessageStatusError, err.Error())
 		return err
 	}
-	logrus.LogrusLoggerWithContext(&ctx).Info("Download finished")
+	logrus.LogrusLoggerWithContext(ctx).Info("Download finished")
 	return nil
 }
+
+func (a *App) CancelDownload(jobID string) error {
+	return a.jobs.Cancel(jobID)
+}
+
+func (a *App) ListJobs() []job.Job {
+	return a.jobs.List()
+}
+
+func (a *App) ListHistory() ([]history.Entry, error) {
+	return history.Default().List()
+}
+
+func addHistory(ctx *context.Context, source string, url string, split ytdlp.SplitState, preset string, files []string, startedAt time.Time, errDownload error) {
+	status, message := history.StatusFromError(errDownload, (*ctx).Err() != nil)
+	entry := history.Entry{
+		Url:       url,
+		Split:     split,
+		Preset:    preset,
+		Files:     files,
+		Status:    status,
+		Error:     message,
+		Source:    source,
+		StartedAt: startedAt,
+	}
+	if err := history.Default().Add(entry); err != nil {
+		logrus.LogrusLoggerWithContext(ctx).Error(err.Error())
+	}
+}
@@ -1,6 +1,6 @@
 import React, {useEffect, useState} from 'react'
 import {Button, Form, Input, message, TimePicker} from 'antd'
-import { SetupResources, StartDownload} from '../wailsjs/go/main/App'
+import { RetryResource, SetupResources, StartDownload} from '../wailsjs/go/main/App'
 import {EventsOn, EventsOff} from '../wailsjs/runtime'
 import dayjs from 'dayjs'
 import './App.scss'
@@ -48,11 +48,24 @@ function App() {
       title: string
       description: string
       progress: number
+      downloaded?: number
+      total?: number
+      speed?: number
+      eta?: number
     }) => {
+      let detail = `${data.progress.toFixed(1)}%`
+      if (data.downloaded) {
+        const mb = (bytes: number) => `${(bytes / 1e6).toFixed(1)} MB`
+        detail = data.total && data.total > 0
+          ? `${detail}, ${mb(data.downloaded)} / ${mb(data.total)}`
+          : mb(data.downloaded)
+        if (data.speed) detail += `, ${mb(data.speed)}/s`
+        if (data.eta) detail += `, ${Math.ceil(data.eta)}s left`
+      }
       message.open({
         type: 'info',
         key: data.key,
-        content: `${data.title}: ${data.description} (${data.progress}%)`,
+        content: `${data.title}: ${data.description} (${detail})`,
         duration: 0
       })
     })
@@ -64,9 +77,43 @@ function App() {
       })
     })
 
+    EventsOn('resource-summary', (summary: {
+      results: { key: string, title: string, status: string, error: string }[]
+      ready: boolean
+    }) => {
+      summary.results
+        .filter((result) => result.status === 'failed')
+        .forEach((result) => {
+          message.open({
+            type: 'error',
+            key: `resource-failed-${result.key}`,
+            duration: 0,
+            content: (
+              <span>
+                {result.title}: {result.error}{' '}
+                <Button size="small" onClick={() => {
+                  message.destroy(`resource-failed-${result.key}`)
+                  RetryResource(result.key).catch(() => {})
+                }}>Retry</Button>
+              </span>
+            ),
+          })
+        })
+      summary.results
+        .filter((result) => result.status === 'unavailable')
+        .forEach((result) => {
+          message.open({
+            type: 'warning',
+            key: `resource-unavailable-${result.key}`,
+            duration: 0,
+            content: `${result.title}: ${result.error}`,
+          })
+        })
+    })
+
     EventsOn('resource-finish', () => {
       setIsReady(true)
-      message.destroy()
+      message.destroy('resource-progress')
       message.open({
         type: 'success',
         content: 'Resource is ready',
@@ -83,6 +130,7 @@ function App() {
 
     return () => {
       EventsOff('resource-finish')
+      EventsOff('resource-summary')
       EventsOff('resource-error')
       EventsOff('resource-progress')
       EventsOff('resource-start')
@@ -1,7 +1,107 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {scheduler} from '../models';
+import {subscription} from '../models';
+import {setup} from '../models';
+import {diagnose} from '../models';
+import {job} from '../models';
+import {settings} from '../models';
+import {resource} from '../models';
+import {history} from '../models';
+import {main} from '../models';
 import {ytdlp} from '../models';
+import {highlight} from '../models';
 
-export function SetupResources():Promise<void>;
+export function AddSchedule(arg1:scheduler.Schedule):Promise<scheduler.Schedule>;
+
+export function AddSubscription(arg1:subscription.Subscription):Promise<subscription.Subscription>;
+
+export function CancelDownload(arg1:string):Promise<void>;
+
+export function CancelTask(arg1:string):Promise<void>;
+
+export function CheckResourceUpdates():Promise<Array<setup.UpdateInfo>>;
+
+export function CheckSubscription(arg1:string):Promise<void>;
+
+export function DeleteSchedule(arg1:string):Promise<void>;
+
+export function DeleteSubscription(arg1:string):Promise<void>;
+
+export function Diagnose():Promise<diagnose.Report>;
+
+export function EnqueueFile(arg1:string,arg2:string):Promise<job.Task>;
+
+export function ExportResourceBundle(arg1:string):Promise<setup.BundleManifest>;
+
+export function GetDefaultSettings():Promise<settings.Settings>;
+
+export function GetResourcePaths():Promise<Array<resource.Resolution>>;
+
+export function GetSettings():Promise<settings.Settings>;
+
+export function InstallNativeHost(arg1:string,arg2:string):Promise<string>;
+
+export function InstallResourcesFromBundle(arg1:string):Promise<setup.BundleManifest>;
+
+export function ListHistory():Promise<Array<history.Entry>>;
+
+export function ListJobs():Promise<Array<job.Job>>;
+
+export function ListNativeHostBrowsers():Promise<Array<string>>;
+
+export function ListPodcastFeeds():Promise<Array<main.PodcastFeed>>;
+
+export function ListPresets():Promise<Array<ytdlp.Preset>>;
+
+export function ListQueue():Promise<Array<job.Task>>;
+
+export function ListSchedules():Promise<Array<scheduler.Schedule>>;
+
+export function ListSubscriptions():Promise<Array<subscription.Subscription>>;
+
+export function PauseSchedule(arg1:string):Promise<void>;
+
+export function PauseSubscription(arg1:string):Promise<void>;
+
+export function ProbeUrl(arg1:string):Promise<ytdlp.ProbeInfo>;
+
+export function RegisterUrlScheme():Promise<void>;
+
+export function ResetControlApiToken():Promise<string>;
+
+export function ResumeSchedule(arg1:string):Promise<void>;
+
+export function ResumeSubscription(arg1:string):Promise<void>;
+
+export function RetryResource(arg1:string):Promise<setup.Summary>;
+
+export function RollbackResource(arg1:string):Promise<setup.UpdateInfo>;
+
+export function SaveReplay(arg1:string,arg2:number):Promise<string>;
+
+export function SearchTranscript(arg1:string,arg2:string,arg3:string):Promise<Array<ytdlp.TranscriptMatch>>;
+
+export function SetupResources():Promise<setup.Summary>;
+
+export function StartControlApi(arg1:number):Promise<main.ControlApiInfo>;
 
 export function StartDownload(arg1:string,arg2:ytdlp.SplitState):Promise<void>;
+
+export function StartPodcastServer(arg1:number):Promise<string>;
+
+export function StartRecording(arg1:string,arg2:ytdlp.RecordOptions):Promise<string>;
+
+export function StartReplayBuffer(arg1:string,arg2:ytdlp.ReplayOptions):Promise<string>;
+
+export function StartTranscriptClip(arg1:string,arg2:ytdlp.TranscriptMatch,arg3:ytdlp.ClipPadding):Promise<void>;
+
+export function StopControlApi():Promise<void>;
+
+export function StopPodcastServer():Promise<void>;
+
+export function SuggestHighlights(arg1:string,arg2:highlight.Options):Promise<Array<highlight.Suggestion>>;
+
+export function UpdateResource(arg1:string):Promise<setup.UpdateInfo>;
+
+export function UpdateSettings(arg1:settings.Settings):Promise<settings.Settings>;
@@ -2,10 +2,190 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AddSchedule(arg1) {
+  return window['go']['main']['App']['AddSchedule'](arg1);
+}
+
+export function AddSubscription(arg1) {
+  return window['go']['main']['App']['AddSubscription'](arg1);
+}
+
+export function CancelDownload(arg1) {
+  return window['go']['main']['App']['CancelDownload'](arg1);
+}
+
+export function CancelTask(arg1) {
+  return window['go']['main']['App']['CancelTask'](arg1);
+}
+
+export function CheckResourceUpdates() {
+  return window['go']['main']['App']['CheckResourceUpdates']();
+}
+
+export function CheckSubscription(arg1) {
+  return window['go']['main']['App']['CheckSubscription'](arg1);
+}
+
+export function DeleteSchedule(arg1) {
+  return window['go']['main']['App']['DeleteSchedule'](arg1);
+}
+
+export function DeleteSubscription(arg1) {
+  return window['go']['main']['App']['DeleteSubscription'](arg1);
+}
+
+export function Diagnose() {
+  return window['go']['main']['App']['Diagnose']();
+}
+
+export function EnqueueFile(arg1, arg2) {
+  return window['go']['main']['App']['EnqueueFile'](arg1, arg2);
+}
+
+export function ExportResourceBundle(arg1) {
+  return window['go']['main']['App']['ExportResourceBundle'](arg1);
+}
+
+export function GetDefaultSettings() {
+  return window['go']['main']['App']['GetDefaultSettings']();
+}
+
+export function GetResourcePaths() {
+  return window['go']['main']['App']['GetResourcePaths']();
+}
+
+export function GetSettings() {
+  return window['go']['main']['App']['GetSettings']();
+}
+
+export function InstallNativeHost(arg1, arg2) {
+  return window['go']['main']['App']['InstallNativeHost'](arg1, arg2);
+}
+
+export function InstallResourcesFromBundle(arg1) {
+  return window['go']['main']['App']['InstallResourcesFromBundle'](arg1);
+}
+
+export function ListHistory() {
+  return window['go']['main']['App']['ListHistory']();
+}
+
+export function ListJobs() {
+  return window['go']['main']['App']['ListJobs']();
+}
+
+export function ListNativeHostBrowsers() {
+  return window['go']['main']['App']['ListNativeHostBrowsers']();
+}
+
+export function ListPodcastFeeds() {
+  return window['go']['main']['App']['ListPodcastFeeds']();
+}
+
+export function ListPresets() {
+  return window['go']['main']['App']['ListPresets']();
+}
+
+export function ListQueue() {
+  return window['go']['main']['App']['ListQueue']();
+}
+
+export function ListSchedules() {
+  return window['go']['main']['App']['ListSchedules']();
+}
+
+export function ListSubscriptions() {
+  return window['go']['main']['App']['ListSubscriptions']();
+}
+
+export function PauseSchedule(arg1) {
+  return window['go']['main']['App']['PauseSchedule'](arg1);
+}
+
+export function PauseSubscription(arg1) {
+  return window['go']['main']['App']['PauseSubscription'](arg1);
+}
+
+export function ProbeUrl(arg1) {
+  return window['go']['main']['App']['ProbeUrl'](arg1);
+}
+
+export function RegisterUrlScheme() {
+  return window['go']['main']['App']['RegisterUrlScheme']();
+}
+
+export function ResetControlApiToken() {
+  return window['go']['main']['App']['ResetControlApiToken']();
+}
+
+export function ResumeSchedule(arg1) {
+  return window['go']['main']['App']['ResumeSchedule'](arg1);
+}
+
+export function ResumeSubscription(arg1) {
+  return window['go']['main']['App']['ResumeSubscription'](arg1);
+}
+
+export function RetryResource(arg1) {
+  return window['go']['main']['App']['RetryResource'](arg1);
+}
+
+export function RollbackResource(arg1) {
+  return window['go']['main']['App']['RollbackResource'](arg1);
+}
+
+export function SaveReplay(arg1, arg2) {
+  return window['go']['main']['App']['SaveReplay'](arg1, arg2);
+}
+
+export function SearchTranscript(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SearchTranscript'](arg1, arg2, arg3);
+}
+
 export function SetupResources() {
   return window['go']['main']['App']['SetupResources']();
 }
 
+export function StartControlApi(arg1) {
+  return window['go']['main']['App']['StartControlApi'](arg1);
+}
+
 export function StartDownload(arg1, arg2) {
   return window['go']['main']['App']['StartDownload'](arg1, arg2);
 }
+
+export function StartPodcastServer(arg1) {
+  return window['go']['main']['App']['StartPodcastServer'](arg1);
+}
+
+export function StartRecording(arg1, arg2) {
+  return window['go']['main']['App']['StartRecording'](arg1, arg2);
+}
+
+export function StartReplayBuffer(arg1, arg2) {
+  return window['go']['main']['App']['StartReplayBuffer'](arg1, arg2);
+}
+
+export function StartTranscriptClip(arg1, arg2, arg3) {
+  return window['go']['main']['App']['StartTranscriptClip'](arg1, arg2, arg3);
+}
+
+export function StopControlApi() {
+  return window['go']['main']['App']['StopControlApi']();
+}
+
+export function StopPodcastServer() {
+  return window['go']['main']['App']['StopPodcastServer']();
+}
+
+export function SuggestHighlights(arg1, arg2) {
+  return window['go']['main']['App']['SuggestHighlights'](arg1, arg2);
+}
+
+export function UpdateResource(arg1) {
+  return window['go']['main']['App']['UpdateResource'](arg1);
+}
+
+export function UpdateSettings(arg1) {
+  return window['go']['main']['App']['UpdateSettings'](arg1);
+}
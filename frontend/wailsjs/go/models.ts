@@ -1,5 +1,983 @@
+export namespace diagnose {
+	
+	export class Check {
+	    name: string;
+	    status: string;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Check(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.status = source["status"];
+	        this.message = source["message"];
+	    }
+	}
+	export class ResourceReport {
+	    key: string;
+	    title: string;
+	    path: string;
+	    source: string;
+	    version: string;
+	    executable: boolean;
+	    status: string;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ResourceReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.key = source["key"];
+	        this.title = source["title"];
+	        this.path = source["path"];
+	        this.source = source["source"];
+	        this.version = source["version"];
+	        this.executable = source["executable"];
+	        this.status = source["status"];
+	        this.message = source["message"];
+	    }
+	}
+	export class Report {
+	    // Go type: time
+	    createdAt: any;
+	    platform: string;
+	    homeDir: string;
+	    homeMode: string;
+	    outputDir: string;
+	    resources: ResourceReport[];
+	    checks: Check[];
+	    status: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Report(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	        this.platform = source["platform"];
+	        this.homeDir = source["homeDir"];
+	        this.homeMode = source["homeMode"];
+	        this.outputDir = source["outputDir"];
+	        this.resources = this.convertValues(source["resources"], ResourceReport);
+	        this.checks = this.convertValues(source["checks"], Check);
+	        this.status = source["status"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace highlight {
+	
+	export class Options {
+	    windowSeconds: number;
+	    maxResults: number;
+	    sceneThreshold: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Options(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.windowSeconds = source["windowSeconds"];
+	        this.maxResults = source["maxResults"];
+	        this.sceneThreshold = source["sceneThreshold"];
+	    }
+	}
+	export class Suggestion {
+	    split: ytdlp.SplitState;
+	    start: number;
+	    end: number;
+	    score: number;
+	    reason: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Suggestion(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.split = this.convertValues(source["split"], ytdlp.SplitState);
+	        this.start = source["start"];
+	        this.end = source["end"];
+	        this.score = source["score"];
+	        this.reason = source["reason"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace history {
+	
+	export class Entry {
+	    id: string;
+	    url: string;
+	    split: ytdlp.SplitState;
+	    preset: string;
+	    files: string[];
+	    status: string;
+	    error: string;
+	    source: string;
+	    // Go type: time
+	    startedAt: any;
+	    // Go type: time
+	    finishedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Entry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.url = source["url"];
+	        this.split = this.convertValues(source["split"], ytdlp.SplitState);
+	        this.preset = source["preset"];
+	        this.files = source["files"];
+	        this.status = source["status"];
+	        this.error = source["error"];
+	        this.source = source["source"];
+	        this.startedAt = this.convertValues(source["startedAt"], null);
+	        this.finishedAt = this.convertValues(source["finishedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace job {
+	
+	export class Job {
+	    id: string;
+	    kind: string;
+	    url: string;
+	    // Go type: time
+	    startedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Job(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.kind = source["kind"];
+	        this.url = source["url"];
+	        this.startedAt = this.convertValues(source["startedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Task {
+	    id: string;
+	    url: string;
+	    title: string;
+	    split: ytdlp.SplitState;
+	    options: ytdlp.DownloadOptions;
+	    direct: boolean;
+	    sha256?: string;
+	    source: string;
+	    key: string;
+	    status: string;
+	    error: string;
+	    jobId: string;
+	    // Go type: time
+	    enqueuedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Task(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.url = source["url"];
+	        this.title = source["title"];
+	        this.split = this.convertValues(source["split"], ytdlp.SplitState);
+	        this.options = this.convertValues(source["options"], ytdlp.DownloadOptions);
+	        this.direct = source["direct"];
+	        this.sha256 = source["sha256"];
+	        this.source = source["source"];
+	        this.key = source["key"];
+	        this.status = source["status"];
+	        this.error = source["error"];
+	        this.jobId = source["jobId"];
+	        this.enqueuedAt = this.convertValues(source["enqueuedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace main {
+	
+	export class ControlApiInfo {
+	    url: string;
+	    token: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ControlApiInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.url = source["url"];
+	        this.token = source["token"];
+	    }
+	}
+	export class PodcastFeed {
+	    subscriptionId: string;
+	    name: string;
+	    url: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PodcastFeed(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.subscriptionId = source["subscriptionId"];
+	        this.name = source["name"];
+	        this.url = source["url"];
+	    }
+	}
+
+}
+
+export namespace resource {
+	
+	export class Rejection {
+	    source: string;
+	    path: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Rejection(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.source = source["source"];
+	        this.path = source["path"];
+	    }
+	}
+	export class Resolution {
+	    key: string;
+	    title: string;
+	    path: string;
+	    source: string;
+	    version: string;
+	    rejected?: Rejection[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Resolution(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.key = source["key"];
+	        this.title = source["title"];
+	        this.path = source["path"];
+	        this.source = source["source"];
+	        this.version = source["version"];
+	        this.rejected = this.convertValues(source["rejected"], Rejection);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace scheduler {
+	
+	export class Schedule {
+	    id: string;
+	    name: string;
+	    action: string;
+	    url: string;
+	    split: ytdlp.SplitState;
+	    record: ytdlp.RecordOptions;
+	    at?: string;
+	    cron?: string;
+	    paused: boolean;
+	    // Go type: time
+	    nextRun: any;
+	    // Go type: time
+	    lastRun: any;
+	    lastError: string;
+	    // Go type: time
+	    createdAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Schedule(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.action = source["action"];
+	        this.url = source["url"];
+	        this.split = this.convertValues(source["split"], ytdlp.SplitState);
+	        this.record = this.convertValues(source["record"], ytdlp.RecordOptions);
+	        this.at = source["at"];
+	        this.cron = source["cron"];
+	        this.paused = source["paused"];
+	        this.nextRun = this.convertValues(source["nextRun"], null);
+	        this.lastRun = this.convertValues(source["lastRun"], null);
+	        this.lastError = source["lastError"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace settings {
+	
+	export class ControlApi {
+	    enabled: boolean;
+	    port: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ControlApi(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.port = source["port"];
+	    }
+	}
+	export class ResourceSource {
+	    order: string[];
+	    customPath: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ResourceSource(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.order = source["order"];
+	        this.customPath = source["customPath"];
+	    }
+	}
+	export class Resources {
+	    ffmpegUrl: string;
+	    ytDlpUrl: string;
+	    ffmpegSha256: string;
+	    ytDlpSha256: string;
+	    allowUnverified: boolean;
+	    mirrors: string[];
+	    minSpeed: number;
+	    ffmpeg: ResourceSource;
+	    ytDlp: ResourceSource;
+	
+	    static createFrom(source: any = {}) {
+	        return new Resources(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ffmpegUrl = source["ffmpegUrl"];
+	        this.ytDlpUrl = source["ytDlpUrl"];
+	        this.ffmpegSha256 = source["ffmpegSha256"];
+	        this.ytDlpSha256 = source["ytDlpSha256"];
+	        this.allowUnverified = source["allowUnverified"];
+	        this.mirrors = source["mirrors"];
+	        this.minSpeed = source["minSpeed"];
+	        this.ffmpeg = this.convertValues(source["ffmpeg"], ResourceSource);
+	        this.ytDlp = this.convertValues(source["ytDlp"], ResourceSource);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Updates {
+	    endpoint: string;
+	    channel: string;
+	    pinnedVersion: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Updates(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.endpoint = source["endpoint"];
+	        this.channel = source["channel"];
+	        this.pinnedVersion = source["pinnedVersion"];
+	    }
+	}
+	export class Settings {
+	    version: number;
+	    outputDir: string;
+	    preset: string;
+	    logLevel: string;
+	    resources: Resources;
+	    controlApi: ControlApi;
+	    updates: Updates;
+	
+	    static createFrom(source: any = {}) {
+	        return new Settings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.outputDir = source["outputDir"];
+	        this.preset = source["preset"];
+	        this.logLevel = source["logLevel"];
+	        this.resources = this.convertValues(source["resources"], Resources);
+	        this.controlApi = this.convertValues(source["controlApi"], ControlApi);
+	        this.updates = this.convertValues(source["updates"], Updates);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace setup {
+	
+	export class BundleResource {
+	    key: string;
+	    version: string;
+	    url: string;
+	    mirror: string;
+	    sha256: string;
+	    // Go type: time
+	    installedAt: any;
+	    executables: {[key: string]: string};
+	    // Go type: resource
+	    previous?: any;
+	    files: {[key: string]: string};
+	
+	    static createFrom(source: any = {}) {
+	        return new BundleResource(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.key = source["key"];
+	        this.version = source["version"];
+	        this.url = source["url"];
+	        this.mirror = source["mirror"];
+	        this.sha256 = source["sha256"];
+	        this.installedAt = this.convertValues(source["installedAt"], null);
+	        this.executables = source["executables"];
+	        this.previous = this.convertValues(source["previous"], null);
+	        this.files = source["files"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class BundleManifest {
+	    version: number;
+	    platform: string;
+	    // Go type: time
+	    createdAt: any;
+	    resources: BundleResource[];
+	
+	    static createFrom(source: any = {}) {
+	        return new BundleManifest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.platform = source["platform"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	        this.resources = this.convertValues(source["resources"], BundleResource);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class Result {
+	    key: string;
+	    title: string;
+	    status: string;
+	    error: string;
+	    resolution: resource.Resolution;
+	
+	    static createFrom(source: any = {}) {
+	        return new Result(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.key = source["key"];
+	        this.title = source["title"];
+	        this.status = source["status"];
+	        this.error = source["error"];
+	        this.resolution = this.convertValues(source["resolution"], resource.Resolution);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Summary {
+	    results: Result[];
+	    ready: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Summary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.results = this.convertValues(source["results"], Result);
+	        this.ready = source["ready"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class UpdateInfo {
+	    key: string;
+	    title: string;
+	    channel: string;
+	    source: string;
+	    current: string;
+	    latest: string;
+	    available: boolean;
+	    canRollback: boolean;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new UpdateInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.key = source["key"];
+	        this.title = source["title"];
+	        this.channel = source["channel"];
+	        this.source = source["source"];
+	        this.current = source["current"];
+	        this.latest = source["latest"];
+	        this.available = source["available"];
+	        this.canRollback = source["canRollback"];
+	        this.error = source["error"];
+	    }
+	}
+
+}
+
+export namespace subscription {
+	
+	export class Filters {
+	    titleRegex: string;
+	    minDuration: number;
+	    maxDuration: number;
+	    dateAfter: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Filters(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.titleRegex = source["titleRegex"];
+	        this.minDuration = source["minDuration"];
+	        this.maxDuration = source["maxDuration"];
+	        this.dateAfter = source["dateAfter"];
+	    }
+	}
+	export class Subscription {
+	    id: string;
+	    name: string;
+	    url: string;
+	    preset: string;
+	    filters: Filters;
+	    intervalMinutes: number;
+	    backfill: boolean;
+	    seeded: boolean;
+	    paused: boolean;
+	    // Go type: time
+	    lastCheck: any;
+	    lastError: string;
+	    // Go type: time
+	    createdAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Subscription(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.url = source["url"];
+	        this.preset = source["preset"];
+	        this.filters = this.convertValues(source["filters"], Filters);
+	        this.intervalMinutes = source["intervalMinutes"];
+	        this.backfill = source["backfill"];
+	        this.seeded = source["seeded"];
+	        this.paused = source["paused"];
+	        this.lastCheck = this.convertValues(source["lastCheck"], null);
+	        this.lastError = source["lastError"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
 export namespace ytdlp {
 	
+	export class ClipPadding {
+	    before: number;
+	    after: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ClipPadding(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.before = source["before"];
+	        this.after = source["after"];
+	    }
+	}
+	export class DownloadOptions {
+	    preset: string;
+	    useArchive: boolean;
+	    dateAfter: string;
+	    matchFilter?: string;
+	    outputTemplate: string;
+	    writeInfoJson: boolean;
+	    cookiesFile?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DownloadOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.preset = source["preset"];
+	        this.useArchive = source["useArchive"];
+	        this.dateAfter = source["dateAfter"];
+	        this.matchFilter = source["matchFilter"];
+	        this.outputTemplate = source["outputTemplate"];
+	        this.writeInfoJson = source["writeInfoJson"];
+	        this.cookiesFile = source["cookiesFile"];
+	    }
+	}
+	export class Preset {
+	    name: string;
+	    title: string;
+	    args: string[];
+	    audioOnly: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Preset(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.title = source["title"];
+	        this.args = source["args"];
+	        this.audioOnly = source["audioOnly"];
+	    }
+	}
+	export class ProbeInfo {
+	    id: string;
+	    title: string;
+	    duration: number;
+	    extractor: string;
+	    uploader: string;
+	    thumbnail: string;
+	    webpageUrl: string;
+	    isLive: boolean;
+	    formats: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProbeInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.title = source["title"];
+	        this.duration = source["duration"];
+	        this.extractor = source["extractor"];
+	        this.uploader = source["uploader"];
+	        this.thumbnail = source["thumbnail"];
+	        this.webpageUrl = source["webpageUrl"];
+	        this.isLive = source["isLive"];
+	        this.formats = source["formats"];
+	    }
+	}
+	export class RecordOptions {
+	    waitForStream: boolean;
+	    fromStart: boolean;
+	    maxDuration: number;
+	    stopAt: string;
+	    segmentSeconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new RecordOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.waitForStream = source["waitForStream"];
+	        this.fromStart = source["fromStart"];
+	        this.maxDuration = source["maxDuration"];
+	        this.stopAt = source["stopAt"];
+	        this.segmentSeconds = source["segmentSeconds"];
+	    }
+	}
+	export class ReplayOptions {
+	    bufferMinutes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ReplayOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.bufferMinutes = source["bufferMinutes"];
+	    }
+	}
 	export class SplitState {
 	    start: string;
 	    end: string;
@@ -14,6 +992,26 @@ export namespace ytdlp {
 	        this.end = source["end"];
 	    }
 	}
+	export class TranscriptMatch {
+	    start: number;
+	    end: number;
+	    startTime: string;
+	    endTime: string;
+	    text: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TranscriptMatch(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.start = source["start"];
+	        this.end = source["end"];
+	        this.startTime = source["startTime"];
+	        this.endTime = source["endTime"];
+	        this.text = source["text"];
+	    }
+	}
 
 }
 
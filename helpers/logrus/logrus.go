@@ -180,6 +180,12 @@ func LogrusLoggerWithContext(ctx *context.Context) *LogrusLogger {
 }
 
 func InitLogrusLogger() {
+	InitLogrusLoggerWithConsole(os.Stdout)
+}
+
+// InitLogrusLoggerWithConsole logs to the log file and to console, which may
+// be nil to keep the console free, e.g. for the cli json output.
+func InitLogrusLoggerWithConsole(console io.Writer) {
 	loggerInit = logrus.New()
 
 	customFormatter := &CustomTextFormatter{}
@@ -187,9 +193,27 @@ func InitLogrusLogger() {
 	// Set the custom formatter as the formatter for the logger
 	loggerInit.SetFormatter(customFormatter)
 	loggerInit.SetLevel(logrus.TraceLevel)
-	f, _ := os.OpenFile(logFilePath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
-	mw := io.MultiWriter(os.Stdout, f)
-	loggerInit.SetOutput(mw)
+	writers := make([]io.Writer, 0, 2)
+	if console != nil {
+		writers = append(writers, console)
+	}
+	_ = utils.CheckOrCreateDir(utils.GetHomeDir())
+	if f, err := os.OpenFile(logFilePath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666); err == nil {
+		writers = append(writers, f)
+	}
+	loggerInit.SetOutput(io.MultiWriter(writers...))
+}
+
+// SetLevel changes the lowest level written, e.g. "debug" or "warn".
+func SetLevel(level string) error {
+	parsed, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	if loggerInit != nil {
+		loggerInit.SetLevel(parsed)
+	}
+	return nil
 }
 
 func NewLogrusLogger() *LogrusLogger {
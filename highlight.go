@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"ytdlp/helpers/logrus"
+	"ytdlp/services/highlight"
+	"ytdlp/services/job"
+	ytdlp "ytdlp/services/yt-dlp"
+	"ytdlp/utils/emit"
+)
+
+// SuggestHighlights accepts a local file or a video url, urls are downloaded
+// into the cache first so repeated analysis does not fetch the video again.
+func (a *App) SuggestHighlights(source string, options highlight.Options) ([]highlight.Suggestion, error) {
+	j := a.jobs.Start(a.ctx, job.KindHighlight, source)
+	defer a.jobs.Finish(j.ID)
+	ctx := *j.Ctx
+	// analysis reports through the download events of the job, the stage of
+	// the progress tells it apart from fetching the source
+	emitDownload := emit.NewEmitJobDownload(&ctx, j.ID)
+	filePath := source
+	if _, errStat := os.Stat(source); errStat != nil {
+		emitDownload.Progress(emit.DownloadStatusDownload, highlight.Progress{Stage: highlight.StageCache})
+		ytd := ytdlp.NewYtDlp(&ctx, source, ytdlp.SplitState{}, emitDownload)
+		cached, err := ytd.DownloadToCache()
+		if err != nil {
+			emit.Message(&ctx, emit.MessageStatusError, err.Error())
+			return nil, err
+		}
+		filePath = cached
+	}
+	suggestions, err := highlight.NewHighlight(&ctx, filePath, options, emitDownload).Suggest()
+	if err != nil {
+		emit.Message(&ctx, emit.MessageStatusError, err.Error())
+		return nil, err
+	}
+	logrus.LogrusLoggerWithContext(&ctx).Infof("Highlight analysis finished for %s", filePath)
+	return suggestions, nil
+}
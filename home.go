@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"ytdlp/utils"
+)
+
+// takeHomeFlag applies --home <dir> or --home=<dir> from anywhere before a
+// "--" and returns the other arguments.
+func takeHomeFlag(args []string) ([]string, error) {
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		var dir string
+		switch {
+		case arg == "--home" || arg == "-home":
+			if i+1 >= len(args) {
+				return nil, errors.New("--home needs a folder")
+			}
+			i++
+			dir = args[i]
+		case strings.HasPrefix(arg, "--home="), strings.HasPrefix(arg, "-home="):
+			_, dir, _ = strings.Cut(arg, "=")
+		default:
+			rest = append(rest, arg)
+			continue
+		}
+		if err := utils.SetHomeDir(dir); err != nil {
+			return nil, err
+		}
+	}
+	return rest, nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"ytdlp/helpers/logrus"
+	"ytdlp/services/instance"
+	"ytdlp/services/job"
+	ytdlp "ytdlp/services/yt-dlp"
+	"ytdlp/utils"
+	"ytdlp/utils/emit"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// RegisterUrlScheme makes this binary the handler of ytdlp:// links, for
+// builds that were not set up by an installer.
+func (a *App) RegisterUrlScheme() error {
+	return instance.RegisterScheme(utils.HomeArgs())
+}
+
+// openUrl handles links macOS hands to the running app instead of starting
+// a second process.
+func (a *App) openUrl(raw string) {
+	link, err := instance.ParseLink(raw)
+	if err != nil {
+		logrus.LogrusLoggerWithContext(&a.ctx).Warn(err.Error())
+		return
+	}
+	a.instance.Deliver([]instance.Link{link})
+}
+
+// openLinks queues links forwarded by another launch and brings the window
+// to the front.
+func (a *App) openLinks(links []instance.Link) {
+	runtime.WindowUnminimise(a.ctx)
+	runtime.WindowShow(a.ctx)
+	for _, link := range links {
+		task := a.queue.Enqueue(job.Task{
+			Url:     link.Url,
+			Split:   link.Split,
+			Options: ytdlp.DownloadOptions{Preset: link.Preset},
+			Source:  "link",
+		})
+		logrus.LogrusLoggerWithContext(&a.ctx).Infof("Queued %s from a link as task %s", link.Url, task.ID)
+		emit.Message(&a.ctx, emit.MessageStatusInfo, fmt.Sprintf("Queued %s", link.Url))
+	}
+}
@@ -2,24 +2,51 @@ package main
 
 import (
 	"embed"
+	"errors"
 	"fmt"
+	"os"
 	"ytdlp/helpers/logrus"
+	"ytdlp/services/instance"
+	"ytdlp/services/nativehost"
+	"ytdlp/utils/settings"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
 	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
+	"github.com/wailsapp/wails/v2/pkg/options/mac"
 )
 
 //go:embed all:frontend/dist
 var assets embed.FS
 
 func main() {
+	// the home dir has to be known before the logger opens its file
+	args, errHome := takeHomeFlag(os.Args[1:])
+	if errHome != nil {
+		println("Home:", errHome.Error())
+		os.Exit(2)
+	}
+	os.Args = append(os.Args[:1], args...)
+	if len(os.Args) > 1 && os.Args[1] == "cli" {
+		os.Exit(runCli(os.Args[2:]))
+	}
+	if nativehost.IsLaunch(os.Args[1:]) {
+		os.Exit(runNativeHost())
+	}
 	// init logger
 	logrus.NewLogrusLogger()
 	logrus.InitLogrusLogger()
+	_ = logrus.SetLevel(settings.Current().LogLevel)
 	appVersion := "1.0.1"
+	// a second launch hands its links to the open window and quits
+	single := instance.New()
+	if err := single.Acquire(instance.LinksFromArgs(os.Args[1:])); errors.Is(err, instance.ErrForwarded) {
+		return
+	} else if err != nil {
+		println("Single instance:", err.Error())
+	}
 	// Create an instance of the app structure
-	app := NewApp()
+	app := NewApp(single)
 
 	// Create application with options
 	err := wails.Run(&options.App{
@@ -32,6 +59,10 @@ func main() {
 		},
 		BackgroundColour: &options.RGBA{R: 247, G: 249, B: 252, A: 1},
 		OnStartup:        app.startup,
+		OnShutdown:       app.shutdown,
+		Mac: &mac.Options{
+			OnUrlOpen: app.openUrl,
+		},
 		Bind: []interface{}{
 			app,
 		},
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"ytdlp/helpers/logrus"
+	"ytdlp/services/nativehost"
+	"ytdlp/utils"
+	"ytdlp/utils/settings"
+)
+
+// InstallNativeHost lets the browser extension with the given id talk to
+// this binary, it returns the path of the written manifest. The host hands
+// downloads to the control api, so it is turned on as well.
+func (a *App) InstallNativeHost(browser string, extensionID string) (string, error) {
+	manifestPath, err := nativehost.Install(browser, extensionID, utils.HomeArgs())
+	if err != nil {
+		return "", err
+	}
+	current := settings.Current()
+	if !current.ControlApi.Enabled {
+		current.ControlApi.Enabled = true
+		if _, errUpdate := a.UpdateSettings(current); errUpdate != nil {
+			return manifestPath, errUpdate
+		}
+	}
+	return manifestPath, nil
+}
+
+func (a *App) ListNativeHostBrowsers() []string {
+	return nativehost.ListBrowsers()
+}
+
+// runNativeHost serves a browser extension over stdio. Stdout carries the
+// protocol, so logs only go to the log file.
+func runNativeHost() int {
+	logrus.InitLogrusLoggerWithConsole(nil)
+	ctx := context.Background()
+	if err := nativehost.Run(&ctx, os.Stdin, os.Stdout); err != nil {
+		logrus.LogrusLoggerWithContext(&ctx).Error(err.Error())
+		return 1
+	}
+	return 0
+}
+
+func cliNativeHost(args []string) int {
+	fs := flag.NewFlagSet("native-host", flag.ContinueOnError)
+	browser := fs.String("browser", "chrome", fmt.Sprintf("browser to register with, one of %v", nativehost.ListBrowsers()))
+	extensionID := fs.String("extension-id", "", "id of the browser extension allowed to connect")
+	if _, err := parseInterspersed(fs, args); err != nil {
+		return 2
+	}
+	logrus.InitLogrusLoggerWithConsole(nil)
+	manifestPath, err := nativehost.Install(*browser, *extensionID, utils.HomeArgs())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	fmt.Fprintf(os.Stdout, "Native host manifest written to %s\n", manifestPath)
+	current, errLoad := settings.Default().Load()
+	if errLoad != nil {
+		fmt.Fprintln(os.Stderr, errLoad.Error())
+		return 1
+	}
+	if !current.ControlApi.Enabled {
+		current.ControlApi.Enabled = true
+		if _, errUpdate := settings.Default().Update(current); errUpdate != nil {
+			fmt.Fprintln(os.Stderr, errUpdate.Error())
+			return 1
+		}
+		fmt.Fprintln(os.Stdout, "Control api turned on, restart ytdlp if it is running")
+	}
+	return 0
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"ytdlp/services/podcast"
+	ytdlp "ytdlp/services/yt-dlp"
+)
+
+type PodcastFeed struct {
+	SubscriptionID string `json:"subscriptionId"`
+	Name           string `json:"name"`
+	Url            string `json:"url"`
+}
+
+// StartPodcastServer serves the audio subscription feeds on the LAN, port 0
+// picks a free port. It returns the base url of the server.
+func (a *App) StartPodcastServer(port int) (string, error) {
+	return a.podcasts.Start(port)
+}
+
+func (a *App) StopPodcastServer() error {
+	return a.podcasts.Stop()
+}
+
+func (a *App) ListPodcastFeeds() []PodcastFeed {
+	feeds := make([]PodcastFeed, 0)
+	for _, sub := range a.subscriptions.List() {
+		preset, err := ytdlp.GetPreset(sub.Preset)
+		if err != nil || !preset.AudioOnly {
+			continue
+		}
+		feeds = append(feeds, PodcastFeed{
+			SubscriptionID: sub.ID,
+			Name:           sub.Name,
+			Url:            a.podcasts.FeedUrl(sub.ID),
+		})
+	}
+	return feeds
+}
+
+func (a *App) startPodcasts() {
+	a.podcasts = podcast.NewServer(&a.ctx, a.subscriptions.Get)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"time"
+	"ytdlp/helpers/logrus"
+	"ytdlp/services/job"
+	ytdlp "ytdlp/services/yt-dlp"
+	"ytdlp/utils"
+	"ytdlp/utils/emit"
+)
+
+func (a *App) ListQueue() []job.Task {
+	return a.queue.List()
+}
+
+func (a *App) CancelTask(taskID string) error {
+	return a.queue.Cancel(taskID)
+}
+
+func (a *App) startQueue() {
+	// cookie files of tasks that never ran in an earlier session
+	if err := utils.CheckOrDeleteDir(ytdlp.GetCookiesDir()); err != nil {
+		logrus.LogrusLoggerWithContext(&a.ctx).Warn(err.Error())
+	}
+	a.queue = job.NewQueue(&a.ctx, a.jobs, a.runTask)
+	a.queue.Start()
+}
+
+func (a *App) runTask(j *job.Job, task job.Task) error {
+	if task.Options.CookiesFile != "" {
+		defer func() {
+			_ = utils.CheckOrDeleteFile(task.Options.CookiesFile)
+		}()
+	}
+	startedAt := time.Now()
+	emitDownload := emit.NewEmitJobDownload(j.Ctx, j.ID)
+	emitDownload.Start()
+	defer emitDownload.Stop()
+	if task.Direct {
+		file, err := downloadDirect(j.Ctx, task.Url, task.Sha256, emitDownload)
+		addHistory(j.Ctx, "queue", task.Url, task.Split, "", fileList(file), startedAt, err)
+		if err != nil {
+			emit.Message(j.Ctx, emit.MessageStatusError, err.Error())
+			return err
+		}
+		logrus.LogrusLoggerWithContext(j.Ctx).Infof("Task %s finished", task.ID)
+		return nil
+	}
+	ytd := ytdlp.NewYtDlp(j.Ctx, task.Url, task.Split, emitDownload)
+	ytd.SetOptions(task.Options)
+	err := ytd.Download()
+	addHistory(j.Ctx, "queue", task.Url, task.Split, task.Options.Preset, ytd.Files(), startedAt, err)
+	if err != nil {
+		emit.Message(j.Ctx, emit.MessageStatusError, err.Error())
+		return err
+	}
+	if task.Options.UseArchive && task.Key != "" {
+		if errArchive := ytdlp.EnsureArchived(task.Key); errArchive != nil {
+			logrus.LogrusLoggerWithContext(j.Ctx).Warn(errArchive.Error())
+		}
+	}
+	logrus.LogrusLoggerWithContext(j.Ctx).Infof("Task %s finished", task.ID)
+	return nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"time"
+	"ytdlp/helpers/logrus"
+	"ytdlp/services/job"
+	ytdlp "ytdlp/services/yt-dlp"
+	"ytdlp/utils/emit"
+)
+
+// StartRecording records a live channel in the background and returns the job
+// id, the recording runs until the stream ends, a limit hits or CancelDownload.
+func (a *App) StartRecording(url string, options ytdlp.RecordOptions) (string, error) {
+	if options.StopAt != "" {
+		if _, err := ytdlp.ParseStopAt(options.StopAt, time.Now()); err != nil {
+			return "", err
+		}
+	}
+	j := a.jobs.Start(a.ctx, job.KindRecord, url)
+	go func() {
+		defer a.jobs.Finish(j.ID)
+		ctx := j.Ctx
+		recorder := ytdlp.NewRecorder(ctx, url, options, emit.NewEmitJobDownload(ctx, j.ID))
+		outDir, err := recorder.Record()
+		if err != nil {
+			emit.Message(ctx, emit.MessageStatusError, err.Error())
+			return
+		}
+		logrus.LogrusLoggerWithContext(ctx).Infof("Recording saved to %s", outDir)
+		emit.Message(ctx, emit.MessageStatusSuccess, fmt.Sprintf("Recording saved to %s", outDir))
+	}()
+	return j.ID, nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"ytdlp/helpers/logrus"
+	"ytdlp/services/job"
+	ytdlp "ytdlp/services/yt-dlp"
+	"ytdlp/utils/emit"
+)
+
+// StartReplayBuffer watches a live channel and keeps a rolling buffer on disk
+// until the job is cancelled, clips are taken from it with SaveReplay.
+func (a *App) StartReplayBuffer(url string, options ytdlp.ReplayOptions) (string, error) {
+	j := a.jobs.Start(a.ctx, job.KindReplay, url)
+	buffer := ytdlp.NewReplayBuffer(j.Ctx, url, options, emit.NewEmitJobDownload(j.Ctx, j.ID))
+	a.replaysMu.Lock()
+	a.replays[j.ID] = buffer
+	a.replaysMu.Unlock()
+	go func() {
+		defer a.jobs.Finish(j.ID)
+		if err := buffer.Run(); err != nil {
+			emit.Message(j.Ctx, emit.MessageStatusError, err.Error())
+		}
+		// no new save finds the buffer once it is out of the map, Close waits
+		// for the running ones before removing the segments
+		a.replaysMu.Lock()
+		delete(a.replays, j.ID)
+		a.replaysMu.Unlock()
+		if err := buffer.Close(); err != nil {
+			logrus.LogrusLoggerWithContext(j.Ctx).Error(err.Error())
+		}
+	}()
+	return j.ID, nil
+}
+
+func (a *App) SaveReplay(jobID string, lastSeconds int) (string, error) {
+	a.replaysMu.Lock()
+	buffer, ok := a.replays[jobID]
+	a.replaysMu.Unlock()
+	if !ok {
+		return "", fmt.Errorf("replay buffer %s not found", jobID)
+	}
+	outPath, err := buffer.Save(lastSeconds)
+	if err != nil {
+		emit.Message(&a.ctx, emit.MessageStatusError, err.Error())
+		return "", err
+	}
+	logrus.LogrusLoggerWithContext(&a.ctx).Infof("Replay saved to %s", outPath)
+	emit.Message(&a.ctx, emit.MessageStatusSuccess, fmt.Sprintf("Replay saved to %s", outPath))
+	return outPath, nil
+}
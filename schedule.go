@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"ytdlp/helpers/logrus"
+	"ytdlp/services/scheduler"
+)
+
+func (a *App) AddSchedule(schedule scheduler.Schedule) (scheduler.Schedule, error) {
+	return a.scheduler.Add(schedule)
+}
+
+func (a *App) ListSchedules() []scheduler.Schedule {
+	return a.scheduler.List()
+}
+
+func (a *App) PauseSchedule(id string) error {
+	return a.scheduler.Pause(id)
+}
+
+func (a *App) ResumeSchedule(id string) error {
+	return a.scheduler.Resume(id)
+}
+
+func (a *App) DeleteSchedule(id string) error {
+	return a.scheduler.Delete(id)
+}
+
+func (a *App) startScheduler() {
+	a.scheduler = scheduler.NewScheduler(&a.ctx, a.runSchedule)
+	if err := a.scheduler.Load(); err != nil {
+		logrus.LogrusLoggerWithContext(&a.ctx).Error(err.Error())
+	}
+	a.scheduler.Start()
+}
+
+// runSchedule feeds a due schedule into the same pipeline as the UI buttons.
+func (a *App) runSchedule(schedule scheduler.Schedule) error {
+	switch schedule.Action {
+	case scheduler.ActionDownload:
+		return a.StartDownload(schedule.Url, schedule.Split)
+	case scheduler.ActionRecord:
+		_, err := a.StartRecording(schedule.Url, schedule.Record)
+		return err
+	default:
+		return fmt.Errorf("unknown schedule action %q", schedule.Action)
+	}
+}
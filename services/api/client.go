@@ -0,0 +1,100 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+	"ytdlp/services/job"
+	"ytdlp/utils"
+)
+
+var errNotRunning = errors.New("ytdlp is not running or its control api is off, open ytdlp and turn on the control api in the settings")
+
+type infoStruct struct {
+	Url string `json:"url"`
+	Pid int    `json:"pid"`
+}
+
+// GetInfoPath is where a running api publishes its url for local clients.
+func GetInfoPath() string {
+	return filepath.Join(utils.GetHomeDir(), "api.json")
+}
+
+func writeInfo(url string) error {
+	data, err := json.Marshal(infoStruct{Url: url, Pid: os.Getpid()})
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(GetInfoPath(), data, 0600)
+}
+
+func removeInfo() {
+	_ = os.Remove(GetInfoPath())
+}
+
+// Client talks to the api of the running app, it is what helper processes
+// like the browser host use to hand over work.
+type Client struct {
+	baseUrl string
+	token   string
+	http    *http.Client
+}
+
+func NewClient() (*Client, error) {
+	data, errRead := os.ReadFile(GetInfoPath())
+	if os.IsNotExist(errRead) {
+		return nil, errNotRunning
+	}
+	if errRead != nil {
+		return nil, errRead
+	}
+	var info infoStruct
+	if err := json.Unmarshal(data, &info); err != nil || info.Url == "" {
+		return nil, errNotRunning
+	}
+	token, errToken := LoadToken()
+	if errToken != nil {
+		return nil, errToken
+	}
+	return &Client{
+		baseUrl: info.Url,
+		token:   token,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (c *Client) Enqueue(request EnqueueRequest) (job.Task, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return job.Task{}, err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.baseUrl+"/api/jobs", bytes.NewReader(body))
+	if err != nil {
+		return job.Task{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		// a stale info file left by a crash looks like this
+		return job.Task{}, errNotRunning
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		var errBody errorStruct
+		if errDecode := json.NewDecoder(resp.Body).Decode(&errBody); errDecode != nil || errBody.Error == "" {
+			return job.Task{}, fmt.Errorf("enqueue: %s", resp.Status)
+		}
+		return job.Task{}, errors.New(errBody.Error)
+	}
+	var task job.Task
+	if err := json.NewDecoder(resp.Body).Decode(&task); err != nil {
+		return job.Task{}, err
+	}
+	return task, nil
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+const (
+	// events buffered per client before the slow client starts missing some
+	clientBuffer = 64
+)
+
+type message struct {
+	event   string
+	payload []byte
+}
+
+type eventStruct struct {
+	Event string      `json:"event"`
+	Data  interface{} `json:"data,omitempty"`
+}
+
+// Broadcaster is an emit.Sink that forwards events to every connected stream
+// client. It costs nothing while nobody listens.
+type Broadcaster struct {
+	mu      sync.Mutex
+	clients map[chan message]struct{}
+}
+
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{
+		clients: map[chan message]struct{}{},
+	}
+}
+
+func (b *Broadcaster) Emit(event string, data ...interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.clients) == 0 {
+		return
+	}
+	payload := eventStruct{Event: event}
+	if len(data) > 0 {
+		payload.Data = data[0]
+	}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	for client := range b.clients {
+		select {
+		case client <- message{event: event, payload: encoded}:
+		default:
+		}
+	}
+}
+
+func (b *Broadcaster) subscribe() chan message {
+	client := make(chan message, clientBuffer)
+	b.mu.Lock()
+	b.clients[client] = struct{}{}
+	b.mu.Unlock()
+	return client
+}
+
+func (b *Broadcaster) unsubscribe(client chan message) {
+	b.mu.Lock()
+	delete(b.clients, client)
+	b.mu.Unlock()
+}
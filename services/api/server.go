@@ -0,0 +1,286 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"ytdlp/helpers/logrus"
+	"ytdlp/services/history"
+	"ytdlp/services/job"
+	ytdlp "ytdlp/services/yt-dlp"
+)
+
+type EnqueueRequest struct {
+	Url    string           `json:"url"`
+	Split  ytdlp.SplitState `json:"split"`
+	Preset string           `json:"preset"`
+	// Cookies is a Netscape cookie file content for sites that need a login
+	Cookies string `json:"cookies,omitempty"`
+	// Direct fetches the url as a plain file instead of through yt-dlp
+	Direct bool `json:"direct,omitempty"`
+	// Sha256 is checked against the finished file of a direct download
+	Sha256 string `json:"sha256,omitempty"`
+}
+
+type JobList struct {
+	Tasks []job.Task `json:"tasks"`
+	Jobs  []job.Job  `json:"jobs"`
+}
+
+// Backend is the part of the app the api drives.
+type Backend interface {
+	Enqueue(request EnqueueRequest) (job.Task, error)
+	List() JobList
+	// Cancel accepts a queue task id or a job id
+	Cancel(id string) error
+	Probe(ctx *context.Context, url string) (ytdlp.ProbeInfo, error)
+	History() ([]history.Entry, error)
+}
+
+type errorStruct struct {
+	Error string `json:"error"`
+}
+
+// Server is the local control api. It only listens on loopback and every
+// request must carry the token.
+type Server struct {
+	ctx     *context.Context
+	backend Backend
+	events  *Broadcaster
+
+	mu      sync.Mutex
+	server  *http.Server
+	baseUrl string
+	token   string
+	// closed on Stop to end the event streams
+	done chan struct{}
+}
+
+func NewServer(ctx *context.Context, backend Backend, events *Broadcaster) *Server {
+	return &Server{
+		ctx:     ctx,
+		backend: backend,
+		events:  events,
+	}
+}
+
+// Start listens on 127.0.0.1, port 0 picks a free port. It returns the base
+// url of the api.
+func (s *Server) Start(port int) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.server != nil {
+		return s.baseUrl, nil
+	}
+	token, errToken := LoadToken()
+	if errToken != nil {
+		return "", errToken
+	}
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return "", err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/jobs", s.handleJobs)
+	mux.HandleFunc("/api/jobs/", s.handleJob)
+	mux.HandleFunc("/api/probe", s.handleProbe)
+	mux.HandleFunc("/api/history", s.handleHistory)
+	mux.HandleFunc("/api/events", s.handleEvents)
+	s.token = token
+	s.done = make(chan struct{})
+	s.server = &http.Server{
+		Handler:           s.authorize(mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	s.baseUrl = fmt.Sprintf("http://%s", listener.Addr().String())
+	if errInfo := writeInfo(s.baseUrl); errInfo != nil {
+		logrus.LogrusLoggerWithContext(s.ctx).Warn(errInfo.Error())
+	}
+	go func(server *http.Server) {
+		if errServe := server.Serve(listener); errServe != nil && !errors.Is(errServe, http.ErrServerClosed) {
+			logrus.LogrusLoggerWithContext(s.ctx).Error(errServe.Error())
+		}
+	}(s.server)
+	logrus.LogrusLoggerWithContext(s.ctx).Infof("Control api listening on %s", s.baseUrl)
+	return s.baseUrl, nil
+}
+
+func (s *Server) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.server == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	close(s.done)
+	removeInfo()
+	err := s.server.Shutdown(ctx)
+	s.server = nil
+	s.baseUrl = ""
+	return err
+}
+
+// ResetToken persists a new token and makes a running server accept only it.
+func (s *Server) ResetToken() (string, error) {
+	token, err := ResetToken()
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	if s.server != nil {
+		s.token = token
+	}
+	s.mu.Unlock()
+	return token, nil
+}
+
+func (s *Server) BaseUrl() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.baseUrl
+}
+
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// browsers send an Origin, only same-machine scripts and tools are served
+		if r.Header.Get("Origin") != "" {
+			writeError(w, http.StatusForbidden, errors.New("cross-origin requests are not allowed"))
+			return
+		}
+		s.mu.Lock()
+		token := s.token
+		s.mu.Unlock()
+		if !validToken(token, requestToken(r)) {
+			writeError(w, http.StatusUnauthorized, errors.New("invalid token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJson(w, http.StatusOK, s.backend.List())
+	case http.MethodPost:
+		var request EnqueueRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&request); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid body: %s", err.Error()))
+			return
+		}
+		task, err := s.backend.Enqueue(request)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeJson(w, http.StatusCreated, task)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/jobs/")
+	if id == "" || strings.Contains(id, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodDelete {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	if err := s.backend.Cancel(id); err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleProbe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	url := r.URL.Query().Get("url")
+	if url == "" {
+		writeError(w, http.StatusBadRequest, errors.New("url is required"))
+		return
+	}
+	ctx := r.Context()
+	info, err := s.backend.Probe(&ctx, url)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJson(w, http.StatusOK, info)
+}
+
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	entries, err := s.backend.History()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if limit, errLimit := strconv.Atoi(r.URL.Query().Get("limit")); errLimit == nil && limit > 0 && limit < len(entries) {
+		entries = entries[:limit]
+	}
+	writeJson(w, http.StatusOK, entries)
+}
+
+// handleEvents streams the events the window gets as server-sent events, the
+// event name is the sse event type and the payload the json data.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming unsupported"))
+		return
+	}
+	s.mu.Lock()
+	done := s.done
+	s.mu.Unlock()
+	client := s.events.subscribe()
+	defer s.events.unsubscribe(client)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	keepAlive := time.NewTicker(30 * time.Second)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-(*s.ctx).Done():
+			return
+		case <-done:
+			return
+		case <-keepAlive.C:
+			_, _ = fmt.Fprint(w, ": keep-alive\n\n")
+		case m := <-client:
+			_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", m.event, m.payload)
+		}
+		flusher.Flush()
+	}
+}
+
+func writeJson(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJson(w, status, errorStruct{Error: err.Error()})
+}
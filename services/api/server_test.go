@@ -0,0 +1,177 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+	"ytdlp/helpers/logrus"
+	"ytdlp/services/history"
+	"ytdlp/services/job"
+	ytdlp "ytdlp/services/yt-dlp"
+	"ytdlp/utils"
+)
+
+// TestMain keeps the token and the api info in a throwaway home dir.
+func TestMain(m *testing.M) {
+	home, err := os.MkdirTemp("", "ytdlp-api")
+	if err != nil {
+		panic(err)
+	}
+	if err := utils.SetHomeDir(home); err != nil {
+		panic(err)
+	}
+	logrus.InitLogrusLoggerWithConsole(nil)
+	code := m.Run()
+	_ = os.RemoveAll(home)
+	os.Exit(code)
+}
+
+// queueBackend enqueues into a queue that is never started, so tasks stay
+// where the test can see them.
+type queueBackend struct {
+	queue *job.Queue
+}
+
+func (b *queueBackend) Enqueue(request EnqueueRequest) (job.Task, error) {
+	if request.Url == "" {
+		return job.Task{}, errors.New("url is required")
+	}
+	return b.queue.Enqueue(job.Task{Url: request.Url, Options: ytdlp.DownloadOptions{Preset: request.Preset}, Source: "api"}), nil
+}
+
+func (b *queueBackend) List() JobList {
+	return JobList{Tasks: b.queue.List()}
+}
+
+func (b *queueBackend) Cancel(id string) error {
+	return b.queue.Cancel(id)
+}
+
+func (b *queueBackend) Probe(ctx *context.Context, url string) (ytdlp.ProbeInfo, error) {
+	return ytdlp.ProbeInfo{}, errors.New("not probing in tests")
+}
+
+func (b *queueBackend) History() ([]history.Entry, error) {
+	return nil, nil
+}
+
+func startServer(t *testing.T) (*queueBackend, *Broadcaster, string, string) {
+	t.Helper()
+	ctx := context.Background()
+	backend := &queueBackend{queue: job.NewQueue(&ctx, job.NewManager(), func(j *job.Job, task job.Task) error { return nil })}
+	events := NewBroadcaster()
+	server := NewServer(&ctx, backend, events)
+	baseUrl, err := server.Start(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = server.Stop() })
+	token, errToken := LoadToken()
+	if errToken != nil {
+		t.Fatal(errToken)
+	}
+	return backend, events, baseUrl, token
+}
+
+func TestAuthorize(t *testing.T) {
+	_, _, baseUrl, token := startServer(t)
+	tests := []struct {
+		name   string
+		query  string
+		header map[string]string
+		want   int
+	}{
+		{name: "no token", want: http.StatusUnauthorized},
+		{name: "wrong token", header: map[string]string{"Authorization": "Bearer " + strings.Repeat("0", 64)}, want: http.StatusUnauthorized},
+		{name: "wrong scheme", header: map[string]string{"Authorization": "Basic " + token}, want: http.StatusUnauthorized},
+		{name: "bearer token", header: map[string]string{"Authorization": "Bearer " + token}, want: http.StatusOK},
+		{name: "query token", query: "?token=" + token, want: http.StatusOK},
+		{name: "foreign origin", header: map[string]string{"Authorization": "Bearer " + token, "Origin": "https://evil.example"}, want: http.StatusForbidden},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, baseUrl+"/api/jobs"+test.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for key, value := range test.header {
+				req.Header.Set(key, value)
+			}
+			resp, errDo := http.DefaultClient.Do(req)
+			if errDo != nil {
+				t.Fatal(errDo)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != test.want {
+				t.Errorf("GET /api/jobs = %d, want %d", resp.StatusCode, test.want)
+			}
+		})
+	}
+}
+
+func TestEnqueue(t *testing.T) {
+	backend, _, baseUrl, token := startServer(t)
+	body := strings.NewReader(`{"url":"https://youtu.be/x","preset":"720p"}`)
+	req, err := http.NewRequest(http.MethodPost, baseUrl+"/api/jobs", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, errDo := http.DefaultClient.Do(req)
+	if errDo != nil {
+		t.Fatal(errDo)
+	}
+	defer resp.Body.Close()
+	var task job.Task
+	if err := json.NewDecoder(resp.Body).Decode(&task); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("POST /api/jobs = %d, want %d", resp.StatusCode, http.StatusCreated)
+	}
+	tasks := backend.queue.List()
+	if len(tasks) != 1 || tasks[0].ID != task.ID || tasks[0].Url != "https://youtu.be/x" || tasks[0].Options.Preset != "720p" {
+		t.Errorf("queue = %+v, want the posted task %+v", tasks, task)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	_, events, baseUrl, token := startServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseUrl+"/api/events?token="+token, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, errDo := http.DefaultClient.Do(req)
+	if errDo != nil {
+		t.Fatal(errDo)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("GET /api/events = %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	// the stream is subscribed once its headers arrived
+	events.Emit("download-progress", map[string]string{"jobId": "j1"})
+	reader := bufio.NewReader(resp.Body)
+	var lines []string
+	for len(lines) < 2 {
+		line, errRead := reader.ReadString('\n')
+		if errRead != nil {
+			t.Fatalf("read stream: %v after %q", errRead, lines)
+		}
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, ":") {
+			lines = append(lines, line)
+		}
+	}
+	want := []string{"event: download-progress", `data: {"event":"download-progress","data":{"jobId":"j1"}}`}
+	if lines[0] != want[0] || lines[1] != want[1] {
+		t.Errorf("stream = %q, want %q", lines, want)
+	}
+}
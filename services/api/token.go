@@ -0,0 +1,60 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"ytdlp/utils"
+)
+
+func GetTokenPath() string {
+	return filepath.Join(utils.GetHomeDir(), "api-token")
+}
+
+// LoadToken returns the persisted api token, creating one on first use so
+// scripts can keep using the same token across restarts.
+func LoadToken() (string, error) {
+	data, errRead := os.ReadFile(GetTokenPath())
+	if errRead == nil {
+		if token := strings.TrimSpace(string(data)); token != "" {
+			return token, nil
+		}
+	} else if !os.IsNotExist(errRead) {
+		return "", errRead
+	}
+	return ResetToken()
+}
+
+// ResetToken replaces the persisted token, clients using the old one are
+// rejected from then on.
+func ResetToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+	if err := utils.CheckOrCreateDir(utils.GetHomeDir()); err != nil {
+		return "", err
+	}
+	if err := utils.WriteFileAtomic(GetTokenPath(), []byte(token), 0600); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// requestToken reads the bearer token, or the token query parameter for
+// clients like EventSource that cannot set headers.
+func requestToken(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+	}
+	return r.URL.Query().Get("token")
+}
+
+func validToken(expected string, actual string) bool {
+	return expected != "" && subtle.ConstantTimeCompare([]byte(expected), []byte(actual)) == 1
+}
@@ -0,0 +1,313 @@
+package highlight
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"ytdlp/helpers/logrus"
+	ytdlp "ytdlp/services/yt-dlp"
+	"ytdlp/utils"
+	"ytdlp/utils/emit"
+	"ytdlp/utils/resource"
+)
+
+const (
+	StageCache    = "cache"
+	StageScene    = "scene"
+	StageLoudness = "loudness"
+)
+
+var (
+	durationRegexp = regexp.MustCompile(`Duration:\s*(\d+):(\d{2}):(\d{2}(?:\.\d+)?)`)
+	ptsTimeRegexp  = regexp.MustCompile(`pts_time:\s*([\d.]+)`)
+	ebur128Regexp  = regexp.MustCompile(`t:\s*([\d.]+)\s+.*?M:\s*(-?[\d.]+|-inf)`)
+)
+
+type Options struct {
+	WindowSeconds  float64 `json:"windowSeconds"`
+	MaxResults     int     `json:"maxResults"`
+	SceneThreshold float64 `json:"sceneThreshold"`
+}
+
+type Suggestion struct {
+	Split  ytdlp.SplitState `json:"split"`
+	Start  float64          `json:"start"`
+	End    float64          `json:"end"`
+	Score  float64          `json:"score"`
+	Reason string           `json:"reason"`
+}
+
+type Progress struct {
+	Stage   string  `json:"stage"`
+	Percent float64 `json:"percent"`
+}
+
+type loudnessSample struct {
+	time     float64
+	loudness float64
+}
+
+type Highlight struct {
+	ctx          *context.Context
+	emitDownload emit.EmitDownload
+	source       string
+	options      Options
+	duration     float64
+}
+
+func NewHighlight(ctx *context.Context, source string, options Options, emitDownload emit.EmitDownload) *Highlight {
+	if options.WindowSeconds <= 0 {
+		options.WindowSeconds = 20
+	}
+	if options.MaxResults <= 0 {
+		options.MaxResults = 10
+	}
+	if options.SceneThreshold <= 0 || options.SceneThreshold >= 1 {
+		options.SceneThreshold = 0.3
+	}
+	return &Highlight{
+		ctx:          ctx,
+		source:       source,
+		options:      options,
+		emitDownload: emitDownload,
+	}
+}
+
+func (h *Highlight) Suggest() ([]Suggestion, error) {
+	h.emitDownload.Start()
+	defer h.emitDownload.Stop()
+	h.duration = h.probeDuration()
+	scenes, errScene := h.detectScenes()
+	if errScene != nil {
+		h.emitDownload.Progress(emit.DownloadStatusError, Progress{Stage: StageScene})
+		return nil, errScene
+	}
+	samples, errLoudness := h.measureLoudness()
+	if errLoudness != nil {
+		h.emitDownload.Progress(emit.DownloadStatusError, Progress{Stage: StageLoudness})
+		return nil, errLoudness
+	}
+	suggestions := h.rank(scenes, samples)
+	h.emitDownload.Progress(emit.DownloadStatusDone, Progress{Stage: StageLoudness, Percent: 100})
+	logrus.LogrusLoggerWithContext(h.ctx).Infof("Found %d highlight candidates in %s", len(suggestions), h.source)
+	return suggestions, nil
+}
+
+func (h *Highlight) detectScenes() ([]float64, error) {
+	scenes := make([]float64, 0)
+	filter := fmt.Sprintf("select='gt(scene,%v)',showinfo", h.options.SceneThreshold)
+	err := h.runFFmpeg(StageScene, []string{"-an", "-vf", filter}, func(line string) {
+		if !strings.Contains(line, "Parsed_showinfo") {
+			return
+		}
+		if match := ptsTimeRegexp.FindStringSubmatch(line); match != nil {
+			if t, errParse := strconv.ParseFloat(match[1], 64); errParse == nil {
+				scenes = append(scenes, t)
+			}
+		}
+	})
+	return scenes, err
+}
+
+func (h *Highlight) measureLoudness() ([]loudnessSample, error) {
+	samples := make([]loudnessSample, 0)
+	err := h.runFFmpeg(StageLoudness, []string{"-vn", "-af", "ebur128"}, func(line string) {
+		if !strings.Contains(line, "Parsed_ebur128") {
+			return
+		}
+		match := ebur128Regexp.FindStringSubmatch(line)
+		if match == nil || match[2] == "-inf" {
+			return
+		}
+		t, errTime := strconv.ParseFloat(match[1], 64)
+		m, errLoudness := strconv.ParseFloat(match[2], 64)
+		if errTime == nil && errLoudness == nil {
+			samples = append(samples, loudnessSample{time: t, loudness: m})
+		}
+	})
+	return samples, err
+}
+
+func (h *Highlight) probeDuration() float64 {
+	cmd := exec.CommandContext(*h.ctx, resource.FFmpegPath(), "-hide_banner", "-i", h.source)
+	utils.HideWindow(cmd)
+	// ffmpeg exits with an error without an output file, the header is still printed
+	output, _ := cmd.CombinedOutput()
+	if match := durationRegexp.FindStringSubmatch(string(output)); match != nil {
+		return parseClock(match[1], match[2], match[3])
+	}
+	return 0
+}
+
+// runFFmpeg decodes the source on the CPU with the given filter arguments,
+// stderr lines go to onLine while -progress output drives the emitted percent.
+func (h *Highlight) runFFmpeg(stage string, filterArgs []string, onLine func(line string)) error {
+	args := []string{"-hide_banner", "-nostats", "-hwaccel", "none", "-i", h.source}
+	args = append(args, filterArgs...)
+	args = append(args, "-progress", "pipe:1", "-f", "null", "-")
+	cmd := exec.CommandContext(*h.ctx, resource.FFmpegPath(), args...)
+	utils.HideWindow(cmd)
+	stdout, errStdout := cmd.StdoutPipe()
+	if errStdout != nil {
+		return errStdout
+	}
+	stderr, errStderr := cmd.StderrPipe()
+	if errStderr != nil {
+		return errStderr
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		h.readProgress(stage, stdout)
+	}()
+	go func() {
+		defer wg.Done()
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			onLine(scanner.Text())
+		}
+	}()
+	wg.Wait()
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("ffmpeg %s analysis failed: %s", stage, err.Error())
+	}
+	return nil
+}
+
+func (h *Highlight) readProgress(stage string, reader io.Reader) {
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok || key != "out_time_us" || h.duration <= 0 {
+			continue
+		}
+		us, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+		percent := math.Min(100, us/1e6/h.duration*100)
+		h.emitDownload.Progress(emit.DownloadStatusProcessing, Progress{Stage: stage, Percent: percent})
+	}
+}
+
+// rank slides a window over the timeline and scores it by how far its
+// loudest moment rises above the median and by its scene-change density.
+func (h *Highlight) rank(scenes []float64, samples []loudnessSample) []Suggestion {
+	duration := h.duration
+	if len(samples) > 0 {
+		duration = math.Max(duration, samples[len(samples)-1].time)
+	}
+	window := h.options.WindowSeconds
+	if duration <= 0 {
+		return []Suggestion{}
+	}
+	if duration < window {
+		window = duration
+	}
+	median := medianLoudness(samples)
+	step := window / 4
+
+	candidates := make([]Suggestion, 0)
+	maxScenes := 0
+	sceneCounts := make([]int, 0)
+	peaks := make([]float64, 0)
+	for start := 0.0; start+window <= duration+0.001; start += step {
+		end := start + window
+		count := 0
+		for _, t := range scenes {
+			if t >= start && t < end {
+				count++
+			}
+		}
+		peak := math.Inf(-1)
+		for _, sample := range samples {
+			if sample.time >= start && sample.time < end && sample.loudness > peak {
+				peak = sample.loudness
+			}
+		}
+		if count > maxScenes {
+			maxScenes = count
+		}
+		sceneCounts = append(sceneCounts, count)
+		peaks = append(peaks, peak)
+		candidates = append(candidates, Suggestion{Start: start, End: end})
+	}
+
+	for i := range candidates {
+		loudScore := 0.0
+		if !math.IsInf(peaks[i], -1) {
+			// 20 LU above the median is treated as the loudest possible peak
+			loudScore = math.Max(0, math.Min(1, (peaks[i]-median)/20))
+		}
+		sceneScore := 0.0
+		if maxScenes > 0 {
+			sceneScore = float64(sceneCounts[i]) / float64(maxScenes)
+		}
+		candidates[i].Score = math.Round((0.6*loudScore+0.4*sceneScore)*1000) / 1000
+		reasons := make([]string, 0, 2)
+		if loudScore > 0 {
+			reasons = append(reasons, fmt.Sprintf("loud peak +%.1f LU", peaks[i]-median))
+		}
+		if sceneCounts[i] > 0 {
+			reasons = append(reasons, fmt.Sprintf("%d scene changes", sceneCounts[i]))
+		}
+		candidates[i].Reason = strings.Join(reasons, ", ")
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+	suggestions := make([]Suggestion, 0, h.options.MaxResults)
+	for _, candidate := range candidates {
+		if len(suggestions) >= h.options.MaxResults || candidate.Score <= 0 {
+			break
+		}
+		overlap := false
+		for _, picked := range suggestions {
+			if candidate.Start < picked.End && picked.Start < candidate.End {
+				overlap = true
+				break
+			}
+		}
+		if overlap {
+			continue
+		}
+		candidate.Split = ytdlp.SplitState{
+			Start: ytdlp.FormatTimestamp(math.Floor(candidate.Start)),
+			End:   ytdlp.FormatTimestamp(math.Ceil(candidate.End)),
+		}
+		suggestions = append(suggestions, candidate)
+	}
+	return suggestions
+}
+
+func medianLoudness(samples []loudnessSample) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	values := make([]float64, len(samples))
+	for i, sample := range samples {
+		values[i] = sample.loudness
+	}
+	sort.Float64s(values)
+	return values[len(values)/2]
+}
+
+func parseClock(hours, minutes, seconds string) float64 {
+	h, _ := strconv.ParseFloat(hours, 64)
+	m, _ := strconv.ParseFloat(minutes, 64)
+	s, _ := strconv.ParseFloat(seconds, 64)
+	return h*3600 + m*60 + s
+}
@@ -0,0 +1,124 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	ytdlp "ytdlp/services/yt-dlp"
+	"ytdlp/utils"
+)
+
+type Status string
+
+const (
+	StatusDone     Status = "done"
+	StatusFailed   Status = "failed"
+	StatusCanceled Status = "canceled"
+)
+
+const (
+	maxEntries = 500
+)
+
+type Entry struct {
+	ID         string           `json:"id"`
+	Url        string           `json:"url"`
+	Split      ytdlp.SplitState `json:"split"`
+	Preset     string           `json:"preset"`
+	Files      []string         `json:"files"`
+	Status     Status           `json:"status"`
+	Error      string           `json:"error"`
+	Source     string           `json:"source"`
+	StartedAt  time.Time        `json:"startedAt"`
+	FinishedAt time.Time        `json:"finishedAt"`
+}
+
+// Store keeps the latest finished downloads in a json file under the home
+// dir, it is shared by the window, the queue and the cli.
+type Store struct {
+	mu   sync.Mutex
+	path string
+}
+
+var (
+	defaultStore     *Store
+	defaultStoreOnce sync.Once
+)
+
+func GetHistoryPath() string {
+	return filepath.Join(utils.GetHomeDir(), "history.json")
+}
+
+func Default() *Store {
+	defaultStoreOnce.Do(func() {
+		defaultStore = &Store{path: GetHistoryPath()}
+	})
+	return defaultStore
+}
+
+func (s *Store) Add(entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := s.read()
+	if err != nil {
+		return err
+	}
+	if entry.ID == "" {
+		entry.ID = utils.GenerateSessionID()
+	}
+	if entry.FinishedAt.IsZero() {
+		entry.FinishedAt = time.Now()
+	}
+	entries = append(entries, entry)
+	if len(entries) > maxEntries {
+		entries = entries[len(entries)-maxEntries:]
+	}
+	data, errJson := json.MarshalIndent(entries, "", "  ")
+	if errJson != nil {
+		return errJson
+	}
+	return utils.WriteFileAtomic(s.path, data, 0644)
+}
+
+// List returns the entries newest first.
+func (s *Store) List() ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
+
+func (s *Store) read() ([]Entry, error) {
+	entries := make([]Entry, 0)
+	data, errRead := os.ReadFile(s.path)
+	if os.IsNotExist(errRead) {
+		return entries, nil
+	}
+	if errRead != nil {
+		return nil, errRead
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("read history: %s", err.Error())
+	}
+	return entries, nil
+}
+
+func StatusFromError(err error, canceled bool) (Status, string) {
+	switch {
+	case canceled:
+		return StatusCanceled, ""
+	case err != nil:
+		return StatusFailed, err.Error()
+	default:
+		return StatusDone, ""
+	}
+}
@@ -0,0 +1,212 @@
+package instance
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"ytdlp/utils"
+)
+
+var ErrForwarded = errors.New("links forwarded to the running instance")
+
+type message struct {
+	Links []Link `json:"links"`
+}
+
+type ack struct {
+	Ok bool `json:"ok"`
+}
+
+// Handler receives what a second launch forwarded, an empty list means the
+// user just opened the app again.
+type Handler func(links []Link)
+
+// Instance owns the local socket other launches forward their links to. It
+// still collects links of this process when the socket is unavailable.
+type Instance struct {
+	listener net.Listener
+
+	mu      sync.Mutex
+	handler Handler
+	pending [][]Link
+}
+
+const (
+	socketName = "instance.sock"
+	// lockName serializes the startup of launches sharing a home dir
+	lockName = "instance.lock"
+	// forwardAttempts is how often a busy instance is asked again
+	forwardAttempts = 3
+)
+
+func GetSocketPath() string {
+	return filepath.Join(utils.GetHomeDir(), socketName)
+}
+
+// IsRunning tells whether an app using homeDir is open, it connects without
+// sending anything so the window is not brought up.
+func IsRunning(homeDir string) bool {
+	conn, err := net.DialTimeout("unix", filepath.Join(homeDir, socketName), time.Second)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+func New() *Instance {
+	return &Instance{}
+}
+
+// Acquire forwards links to the running instance and returns ErrForwarded
+// when there is one, otherwise this process becomes the instance and keeps
+// the links for its own handler. Launches wait for each other, so two of
+// them never both take over the socket.
+func (i *Instance) Acquire(links []Link) error {
+	if err := utils.CheckOrCreateDir(utils.GetHomeDir()); err != nil {
+		return err
+	}
+	unlock, errLock := lockStartup()
+	if errLock != nil {
+		return errLock
+	}
+	defer unlock()
+	errForward := forward(links)
+	if errForward == nil {
+		return ErrForwarded
+	}
+	if len(links) > 0 {
+		i.Deliver(links)
+	}
+	if !isStale(errForward) {
+		// somebody holds the socket, taking it over would strand them
+		return fmt.Errorf("running instance did not answer: %w", errForward)
+	}
+	// nobody listens, so the socket is left over from a crash
+	_ = os.Remove(GetSocketPath())
+	listener, err := net.Listen("unix", GetSocketPath())
+	if err != nil {
+		return err
+	}
+	_ = os.Chmod(GetSocketPath(), 0600)
+	i.listener = listener
+	go i.accept()
+	return nil
+}
+
+// lockStartup holds the startup lock of the home dir until the returned
+// func is called.
+func lockStartup() (func(), error) {
+	file, err := os.OpenFile(filepath.Join(utils.GetHomeDir(), lockName), os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(file); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("lock %s: %s", file.Name(), err.Error())
+	}
+	return func() {
+		_ = unlockFile(file)
+		_ = file.Close()
+	}, nil
+}
+
+// Serve hands forwarded links to handler, the ones that arrived before are
+// delivered right away.
+func (i *Instance) Serve(handler Handler) {
+	i.mu.Lock()
+	i.handler = handler
+	pending := i.pending
+	i.pending = nil
+	i.mu.Unlock()
+	for _, links := range pending {
+		handler(links)
+	}
+}
+
+// Deliver passes links to the handler, or keeps them until Serve is called.
+func (i *Instance) Deliver(links []Link) {
+	i.mu.Lock()
+	handler := i.handler
+	if handler == nil {
+		i.pending = append(i.pending, links)
+	}
+	i.mu.Unlock()
+	if handler != nil {
+		handler(links)
+	}
+}
+
+func (i *Instance) Close() error {
+	if i.listener == nil {
+		return nil
+	}
+	err := i.listener.Close()
+	_ = os.Remove(GetSocketPath())
+	return err
+}
+
+func (i *Instance) accept() {
+	for {
+		conn, err := i.listener.Accept()
+		if err != nil {
+			return
+		}
+		go i.receive(conn)
+	}
+}
+
+func (i *Instance) receive(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	var m message
+	if err := json.NewDecoder(conn).Decode(&m); err != nil {
+		return
+	}
+	_ = json.NewEncoder(conn).Encode(ack{Ok: true})
+	i.Deliver(m.Links)
+}
+
+// forward hands links to the running instance, an instance too busy to
+// answer in time is asked again.
+func forward(links []Link) error {
+	var err error
+	for attempt := 0; attempt < forwardAttempts; attempt++ {
+		if err = forwardOnce(links); err == nil || !isTimeout(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func forwardOnce(links []Link) error {
+	conn, err := net.DialTimeout("unix", GetSocketPath(), time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if err := json.NewEncoder(conn).Encode(message{Links: links}); err != nil {
+		return err
+	}
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return err
+	}
+	var reply ack
+	if err := json.Unmarshal(line, &reply); err != nil || !reply.Ok {
+		return errors.New("running instance refused the links")
+	}
+	return nil
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,48 @@
+package instance
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+	"ytdlp/utils"
+)
+
+func TestAcquire(t *testing.T) {
+	// a socket file left by a crash is taken over
+	if err := os.WriteFile(GetSocketPath(), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	first := New()
+	if err := first.Acquire(nil); err != nil {
+		t.Fatalf("first Acquire() = %v", err)
+	}
+	defer first.Close()
+	received := make(chan []Link, 1)
+	first.Serve(func(links []Link) { received <- links })
+
+	links := []Link{{Url: "https://a.b/c"}}
+	second := New()
+	if err := second.Acquire(links); !errors.Is(err, ErrForwarded) {
+		t.Fatalf("second Acquire() = %v, want ErrForwarded", err)
+	}
+	select {
+	case got := <-received:
+		if !reflect.DeepEqual(got, links) {
+			t.Errorf("forwarded %+v, want %+v", got, links)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("links were not forwarded")
+	}
+	if !IsRunning(utils.GetHomeDir()) {
+		t.Error("IsRunning() = false while the first instance listens")
+	}
+}
+
+func TestIsStale(t *testing.T) {
+	// the socket of TestAcquire is gone with its instance
+	if err := forwardOnce(nil); err == nil || !isStale(err) {
+		t.Errorf("dial without a listener = %v, want a stale error", err)
+	}
+}
@@ -0,0 +1,76 @@
+package instance
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	ytdlp "ytdlp/services/yt-dlp"
+)
+
+// Scheme is registered by the installers, see wails.json.
+const Scheme = "ytdlp"
+
+// Link is a download handed to the app from outside, by a ytdlp:// link or
+// a media url on the command line.
+type Link struct {
+	Url    string           `json:"url"`
+	Split  ytdlp.SplitState `json:"split"`
+	Preset string           `json:"preset"`
+}
+
+// ParseLink reads ytdlp://download?url=...&start=...&end=...&preset=... or a
+// plain http(s) media url.
+func ParseLink(raw string) (Link, error) {
+	raw = strings.TrimSpace(raw)
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return Link{}, fmt.Errorf("invalid link %q", raw)
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "http", "https":
+		return Link{Url: raw}, nil
+	case Scheme:
+	default:
+		return Link{}, fmt.Errorf("unsupported link %q", raw)
+	}
+	// ytdlp://download?... puts the action in the host, ytdlp:download?... in the opaque part
+	action := parsed.Host
+	if action == "" {
+		action = strings.Trim(parsed.Opaque+parsed.Path, "/")
+	}
+	if action != "download" {
+		return Link{}, fmt.Errorf("unknown link action %q", action)
+	}
+	query := parsed.Query()
+	link := Link{
+		Url: query.Get("url"),
+		Split: ytdlp.SplitState{
+			Start: query.Get("start"),
+			End:   query.Get("end"),
+		},
+		Preset: query.Get("preset"),
+	}
+	target, errTarget := url.Parse(link.Url)
+	if errTarget != nil || (target.Scheme != "http" && target.Scheme != "https") {
+		return Link{}, fmt.Errorf("link needs an http url, got %q", link.Url)
+	}
+	if _, err := ytdlp.GetPreset(link.Preset); err != nil {
+		return Link{}, err
+	}
+	return link, nil
+}
+
+// LinksFromArgs picks the links out of the command line, other arguments
+// are ignored.
+func LinksFromArgs(args []string) []Link {
+	links := make([]Link, 0)
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if link, err := ParseLink(arg); err == nil {
+			links = append(links, link)
+		}
+	}
+	return links
+}
@@ -0,0 +1,67 @@
+package instance
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	ytdlp "ytdlp/services/yt-dlp"
+	"ytdlp/utils"
+)
+
+func TestMain(m *testing.M) {
+	home, err := os.MkdirTemp("", "ytdlp-instance")
+	if err != nil {
+		panic(err)
+	}
+	if err := utils.SetHomeDir(home); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	_ = os.RemoveAll(home)
+	os.Exit(code)
+}
+
+func TestParseLink(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    Link
+		wantErr bool
+	}{
+		{name: "plain https url", raw: " https://youtu.be/x ", want: Link{Url: "https://youtu.be/x"}},
+		{name: "plain http url", raw: "http://example.com/v", want: Link{Url: "http://example.com/v"}},
+		{
+			name: "download link",
+			raw:  "ytdlp://download?url=https%3A%2F%2Fyoutu.be%2Fx%3Ft%3D1&start=00:01:00&end=00:02:00&preset=720p",
+			want: Link{Url: "https://youtu.be/x?t=1", Split: ytdlp.SplitState{Start: "00:01:00", End: "00:02:00"}, Preset: "720p"},
+		},
+		{name: "opaque form", raw: "ytdlp:download?url=https://a.b/c&preset=best", want: Link{Url: "https://a.b/c", Preset: "best"}},
+		{name: "scheme is case insensitive", raw: "YTDLP://download?url=https://a.b/c&preset=best", want: Link{Url: "https://a.b/c", Preset: "best"}},
+		{name: "trailing slash after the action", raw: "ytdlp://download/?url=https://a.b/c&preset=best", want: Link{Url: "https://a.b/c", Preset: "best"}},
+		{name: "unknown action", raw: "ytdlp://delete?url=https://a.b/c", wantErr: true},
+		{name: "missing url", raw: "ytdlp://download?preset=best", wantErr: true},
+		{name: "file url", raw: "ytdlp://download?url=file:///etc/passwd", wantErr: true},
+		{name: "unknown preset", raw: "ytdlp://download?url=https://a.b/c&preset=8k", wantErr: true},
+		{name: "other scheme", raw: "ftp://a.b/c", wantErr: true},
+		{name: "not a url", raw: "%zz", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseLink(test.raw)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ParseLink() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if !test.wantErr && !reflect.DeepEqual(got, test.want) {
+				t.Errorf("ParseLink() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLinksFromArgs(t *testing.T) {
+	args := []string{"--home", "/tmp/h", "-psn_0_123", "https://a.b/c", "notes.txt", "ytdlp://download?url=https://d.e/f&preset=best"}
+	want := []Link{{Url: "https://a.b/c"}, {Url: "https://d.e/f", Preset: "best"}}
+	if got := LinksFromArgs(args); !reflect.DeepEqual(got, want) {
+		t.Errorf("LinksFromArgs() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,28 @@
+//go:build !windows
+
+package instance
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lockFile blocks until this process holds an exclusive lock on file.
+func lockFile(file *os.File) error {
+	for {
+		err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX)
+		if !errors.Is(err, syscall.EINTR) {
+			return err
+		}
+	}
+}
+
+func unlockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
+
+// isStale tells whether a dial error means nobody listens on the socket.
+func isStale(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, os.ErrNotExist)
+}
@@ -0,0 +1,14 @@
+package instance
+
+import "errors"
+
+// RegisterScheme is a no-op on macOS, the app bundle declares the scheme in
+// its Info.plist and Launch Services picks it up.
+func RegisterScheme(homeArgs []string) error {
+	return errors.New("on macOS the scheme is registered by the app bundle")
+}
+
+// SchemeRegistered is false on macOS, there is nothing to register again.
+func SchemeRegistered() bool {
+	return false
+}
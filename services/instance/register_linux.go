@@ -0,0 +1,102 @@
+package instance
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"ytdlp/utils"
+)
+
+const desktopFile = "ytdlp-url-handler.desktop"
+
+// RegisterScheme installs a desktop entry for the current binary and makes
+// it the handler of ytdlp:// links for the current user. homeArgs are passed
+// to every launch so links reach the instance of that home dir.
+func RegisterScheme(homeArgs []string) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	entryPath, errPath := desktopFilePath()
+	if errPath != nil {
+		return errPath
+	}
+	if err := utils.CheckOrCreateDir(filepath.Dir(entryPath)); err != nil {
+		return err
+	}
+	entry := strings.Join([]string{
+		"[Desktop Entry]",
+		"Type=Application",
+		"Name=Yt-DLP",
+		fmt.Sprintf("Exec=%s %%u", execLine(executable, homeArgs)),
+		"NoDisplay=true",
+		fmt.Sprintf("MimeType=x-scheme-handler/%s;", Scheme),
+		"",
+	}, "\n")
+	if err := utils.WriteFileAtomic(entryPath, []byte(entry), 0644); err != nil {
+		return err
+	}
+	if output, errCmd := exec.Command("xdg-mime", "default", desktopFile, "x-scheme-handler/"+Scheme).CombinedOutput(); errCmd != nil {
+		return fmt.Errorf("register scheme: %s", strings.TrimSpace(string(output)+" "+errCmd.Error()))
+	}
+	return nil
+}
+
+// SchemeRegistered tells whether the desktop entry starts the current
+// binary.
+func SchemeRegistered() bool {
+	executable, err := os.Executable()
+	if err != nil {
+		return false
+	}
+	entryPath, errPath := desktopFilePath()
+	if errPath != nil {
+		return false
+	}
+	data, errRead := os.ReadFile(entryPath)
+	return errRead == nil && strings.Contains(string(data), "Exec="+quoteExec(executable)+" ")
+}
+
+func desktopFilePath() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "applications", desktopFile), nil
+}
+
+func execLine(executable string, args []string) string {
+	quoted := []string{quoteExec(executable)}
+	for _, arg := range args {
+		quoted = append(quoted, quoteExec(arg))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// quoteExec quotes one argument of an Exec key. The quoting backslashes are
+// escaped once more because the key is read as a string first, and a %
+// would be taken for a field code.
+func quoteExec(arg string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range arg {
+		switch r {
+		case '\\':
+			b.WriteString(`\\\\`)
+			continue
+		case '"', '`', '$':
+			b.WriteString(`\\`)
+		case '%':
+			b.WriteByte('%')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,24 @@
+package instance
+
+import "testing"
+
+func TestQuoteExec(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{arg: "/usr/bin/ytdlp", want: `"/usr/bin/ytdlp"`},
+		{arg: "/home/me/My Videos", want: `"/home/me/My Videos"`},
+		{arg: `/a"b`, want: `"/a\\"b"`},
+		{arg: "/a$b`c", want: "\"/a\\\\$b\\\\`c\""},
+		{arg: `/a\b`, want: `"/a\\\\b"`},
+		{arg: "/100%", want: `"/100%%"`},
+	}
+	for _, test := range tests {
+		t.Run(test.arg, func(t *testing.T) {
+			if got := quoteExec(test.arg); got != test.want {
+				t.Errorf("quoteExec(%q) = %s, want %s", test.arg, got, test.want)
+			}
+		})
+	}
+}
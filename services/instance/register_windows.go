@@ -0,0 +1,54 @@
+package instance
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"ytdlp/utils"
+)
+
+const schemeKey = `HKCU\Software\Classes\` + Scheme
+
+// RegisterScheme makes the current binary open ytdlp:// links for the
+// current user, the installer does the same for installed copies. homeArgs
+// are passed to every launch so links reach the instance of that home dir.
+func RegisterScheme(homeArgs []string) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	command := fmt.Sprintf(`"%s"`, executable)
+	for _, arg := range homeArgs {
+		command += " " + syscall.EscapeArg(arg)
+	}
+	values := [][]string{
+		{schemeKey, "/ve", "/d", "URL:ytdlp download link"},
+		{schemeKey, "/v", "URL Protocol", "/d", ""},
+		{schemeKey + `\DefaultIcon`, "/ve", "/d", executable + ",0"},
+		{schemeKey + `\shell\open\command`, "/ve", "/d", command + ` "%1"`},
+	}
+	for _, value := range values {
+		args := append([]string{"add"}, value...)
+		args = append(args, "/f")
+		cmd := exec.Command("reg", args...)
+		utils.HideWindow(cmd)
+		if output, errCmd := cmd.CombinedOutput(); errCmd != nil {
+			return fmt.Errorf("register scheme: %s", strings.TrimSpace(string(output)))
+		}
+	}
+	return nil
+}
+
+// SchemeRegistered tells whether ytdlp:// links start the current binary.
+func SchemeRegistered() bool {
+	executable, err := os.Executable()
+	if err != nil {
+		return false
+	}
+	cmd := exec.Command("reg", "query", schemeKey+`\shell\open\command`, "/ve")
+	utils.HideWindow(cmd)
+	output, errCmd := cmd.Output()
+	return errCmd == nil && strings.Contains(strings.ToLower(string(output)), strings.ToLower(fmt.Sprintf(`"%s"`, executable)))
+}
@@ -0,0 +1,102 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	"ytdlp/utils"
+)
+
+type Kind string
+
+const (
+	KindDownload  Kind = "download"
+	KindRecord    Kind = "record"
+	KindReplay    Kind = "replay"
+	KindHighlight Kind = "highlight"
+)
+
+type Job struct {
+	ID        string              `json:"id"`
+	Kind      Kind                `json:"kind"`
+	Url       string              `json:"url"`
+	StartedAt time.Time           `json:"startedAt"`
+	Ctx       *context.Context    `json:"-"`
+	Cancel    *context.CancelFunc `json:"-"`
+}
+
+type Manager struct {
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+func NewManager() *Manager {
+	return &Manager{
+		jobs: map[string]*Job{},
+	}
+}
+
+// Start registers a cancellable job derived from parent, callers must call
+// Finish once the job is over.
+func (m *Manager) Start(parent context.Context, kind Kind, url string) *Job {
+	ctx, cancel := context.WithCancel(parent)
+	job := &Job{
+		ID:        utils.GenerateSessionID(),
+		Kind:      kind,
+		Url:       url,
+		StartedAt: time.Now(),
+		Ctx:       &ctx,
+		Cancel:    &cancel,
+	}
+	m.mu.Lock()
+	m.jobs[job.ID] = job
+	m.mu.Unlock()
+	return job
+}
+
+func (m *Manager) Finish(id string) {
+	m.mu.Lock()
+	job, ok := m.jobs[id]
+	delete(m.jobs, id)
+	m.mu.Unlock()
+	if ok {
+		(*job.Cancel)()
+	}
+}
+
+func (m *Manager) Get(id string) (*Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	return job, ok
+}
+
+func (m *Manager) Cancel(id string) error {
+	job, ok := m.Get(id)
+	if !ok {
+		return fmt.Errorf("job %s not found", id)
+	}
+	(*job.Cancel)()
+	return nil
+}
+
+func (m *Manager) CancelAll() {
+	for _, job := range m.List() {
+		(*job.Cancel)()
+	}
+}
+
+func (m *Manager) List() []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	jobs := make([]Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		jobs = append(jobs, *job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].StartedAt.Before(jobs[j].StartedAt)
+	})
+	return jobs
+}
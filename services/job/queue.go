@@ -0,0 +1,209 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+	"ytdlp/helpers/logrus"
+	ytdlp "ytdlp/services/yt-dlp"
+	"ytdlp/utils"
+)
+
+type TaskStatus string
+
+const (
+	TaskStatusQueued   TaskStatus = "queued"
+	TaskStatusRunning  TaskStatus = "running"
+	TaskStatusDone     TaskStatus = "done"
+	TaskStatusFailed   TaskStatus = "failed"
+	TaskStatusCanceled TaskStatus = "canceled"
+)
+
+const (
+	// finished tasks kept around for List
+	queueHistorySize = 100
+)
+
+type Task struct {
+	ID      string                `json:"id"`
+	Url     string                `json:"url"`
+	Title   string                `json:"title"`
+	Split   ytdlp.SplitState      `json:"split"`
+	Options ytdlp.DownloadOptions `json:"options"`
+	// Direct fetches the url as a plain file instead of through yt-dlp
+	Direct bool `json:"direct"`
+	// Sha256 is the expected digest of a direct download, empty skips it
+	Sha256 string `json:"sha256,omitempty"`
+	// Source tells where the task came from, e.g. a subscription id
+	Source     string     `json:"source"`
+	Key        string     `json:"key"`
+	Status     TaskStatus `json:"status"`
+	Error      string     `json:"error"`
+	JobID      string     `json:"jobId"`
+	EnqueuedAt time.Time  `json:"enqueuedAt"`
+}
+
+// Handler runs one task under the job context it is given.
+type Handler func(j *Job, task Task) error
+
+// Queue runs download tasks one after another in the background, every
+// running task is a regular job so it can be cancelled like any other.
+type Queue struct {
+	ctx     *context.Context
+	manager *Manager
+	handler Handler
+
+	mu    sync.Mutex
+	tasks []*Task
+	wake  chan struct{}
+}
+
+func NewQueue(ctx *context.Context, manager *Manager, handler Handler) *Queue {
+	return &Queue{
+		ctx:     ctx,
+		manager: manager,
+		handler: handler,
+		tasks:   make([]*Task, 0),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+func (q *Queue) Start() {
+	go func() {
+		for {
+			task := q.next()
+			if task == nil {
+				select {
+				case <-(*q.ctx).Done():
+					return
+				case <-q.wake:
+				}
+				continue
+			}
+			q.run(task)
+		}
+	}()
+}
+
+func (q *Queue) Enqueue(task Task) Task {
+	task.ID = utils.GenerateSessionID()
+	task.Status = TaskStatusQueued
+	task.Error = ""
+	task.JobID = ""
+	task.EnqueuedAt = time.Now()
+	q.mu.Lock()
+	q.tasks = append(q.tasks, &task)
+	q.trim()
+	q.mu.Unlock()
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return task
+}
+
+// Pending reports whether a queued or running task carries the given key.
+func (q *Queue) Pending(key string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, task := range q.tasks {
+		if task.Key == key && (task.Status == TaskStatusQueued || task.Status == TaskStatusRunning) {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *Queue) List() []Task {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	tasks := make([]Task, 0, len(q.tasks))
+	for _, task := range q.tasks {
+		tasks = append(tasks, *task)
+	}
+	return tasks
+}
+
+func (q *Queue) Cancel(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, task := range q.tasks {
+		if task.ID != id {
+			continue
+		}
+		switch task.Status {
+		case TaskStatusQueued:
+			task.Status = TaskStatusCanceled
+			// the handler never runs, so nothing else removes the cookies
+			if task.Options.CookiesFile != "" {
+				_ = utils.CheckOrDeleteFile(task.Options.CookiesFile)
+				task.Options.CookiesFile = ""
+			}
+			return nil
+		case TaskStatusRunning:
+			return q.manager.Cancel(task.JobID)
+		default:
+			return fmt.Errorf("task %s already finished", id)
+		}
+	}
+	return fmt.Errorf("task %s not found", id)
+}
+
+func (q *Queue) next() *Task {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, task := range q.tasks {
+		if task.Status == TaskStatusQueued {
+			return task
+		}
+	}
+	return nil
+}
+
+func (q *Queue) run(task *Task) {
+	j := q.manager.Start(*q.ctx, KindDownload, task.Url)
+	defer q.manager.Finish(j.ID)
+	q.mu.Lock()
+	task.Status = TaskStatusRunning
+	task.JobID = j.ID
+	snapshot := *task
+	q.mu.Unlock()
+
+	err := q.handler(j, snapshot)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	switch {
+	case (*j.Ctx).Err() != nil:
+		task.Status = TaskStatusCanceled
+	case err != nil:
+		task.Status = TaskStatusFailed
+		task.Error = err.Error()
+		logrus.LogrusLoggerWithContext(q.ctx).Errorf("Task %s failed: %s", task.ID, task.Error)
+	default:
+		task.Status = TaskStatusDone
+	}
+}
+
+// trim drops the oldest finished tasks, callers hold q.mu.
+func (q *Queue) trim() {
+	finished := 0
+	for _, task := range q.tasks {
+		if task.Status != TaskStatusQueued && task.Status != TaskStatusRunning {
+			finished++
+		}
+	}
+	if finished <= queueHistorySize {
+		return
+	}
+	tasks := make([]*Task, 0, len(q.tasks))
+	for _, task := range q.tasks {
+		if finished > queueHistorySize && task.Status != TaskStatusQueued && task.Status != TaskStatusRunning {
+			finished--
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	q.tasks = tasks
+}
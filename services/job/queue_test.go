@@ -0,0 +1,28 @@
+package job
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	ytdlp "ytdlp/services/yt-dlp"
+)
+
+func TestQueueCancelQueuedRemovesCookies(t *testing.T) {
+	cookiesFile := filepath.Join(t.TempDir(), "cookies.txt")
+	if err := os.WriteFile(cookiesFile, []byte("# Netscape HTTP Cookie File\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	q := NewQueue(&ctx, NewManager(), func(j *Job, task Task) error { return nil })
+	task := q.Enqueue(Task{Url: "https://x", Options: ytdlp.DownloadOptions{CookiesFile: cookiesFile}})
+	if err := q.Cancel(task.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(cookiesFile); !os.IsNotExist(err) {
+		t.Errorf("cookie file still there after cancel: %v", err)
+	}
+	if got := q.List()[0]; got.Status != TaskStatusCanceled || got.Options.CookiesFile != "" {
+		t.Errorf("task after cancel = %+v", got)
+	}
+}
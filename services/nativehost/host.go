@@ -0,0 +1,84 @@
+package nativehost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"ytdlp/helpers/logrus"
+	"ytdlp/services/api"
+	ytdlp "ytdlp/services/yt-dlp"
+)
+
+// Name is the host name extensions pass to chrome.runtime.connectNative.
+const Name = "com.goprofilevn.ytdlp"
+
+type Request struct {
+	// Type is "ping" or "download"
+	Type    string         `json:"type"`
+	Url     string         `json:"url"`
+	Start   string         `json:"start"`
+	End     string         `json:"end"`
+	Preset  string         `json:"preset"`
+	Cookies []ytdlp.Cookie `json:"cookies"`
+}
+
+type Response struct {
+	Ok     bool   `json:"ok"`
+	Error  string `json:"error,omitempty"`
+	TaskID string `json:"taskId,omitempty"`
+}
+
+// Run answers the requests of one browser connection until the browser
+// closes stdin. Downloads are handed to the queue of the running app.
+func Run(ctx *context.Context, in io.Reader, out io.Writer) error {
+	for {
+		var request Request
+		if err := ReadMessage(in, &request); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		response := handle(ctx, request)
+		if !response.Ok {
+			logrus.LogrusLoggerWithContext(ctx).Warnf("Native host %s request failed: %s", request.Type, response.Error)
+		}
+		if err := WriteMessage(out, response); err != nil {
+			return err
+		}
+	}
+}
+
+func handle(ctx *context.Context, request Request) Response {
+	switch request.Type {
+	case "ping":
+		return Response{Ok: true}
+	case "download":
+		url := strings.TrimSpace(request.Url)
+		if url == "" {
+			return Response{Error: "url is required"}
+		}
+		client, err := api.NewClient()
+		if err != nil {
+			return Response{Error: err.Error()}
+		}
+		enqueue := api.EnqueueRequest{
+			Url:    url,
+			Split:  ytdlp.SplitState{Start: request.Start, End: request.End},
+			Preset: request.Preset,
+		}
+		if len(request.Cookies) > 0 {
+			enqueue.Cookies = ytdlp.FormatCookies(request.Cookies)
+		}
+		task, err := client.Enqueue(enqueue)
+		if err != nil {
+			return Response{Error: err.Error()}
+		}
+		logrus.LogrusLoggerWithContext(ctx).Infof("Native host queued %s as task %s", url, task.ID)
+		return Response{Ok: true, TaskID: task.ID}
+	default:
+		return Response{Error: fmt.Sprintf("unknown request type %q", request.Type)}
+	}
+}
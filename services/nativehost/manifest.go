@@ -0,0 +1,201 @@
+package nativehost
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"ytdlp/utils"
+)
+
+type Browser struct {
+	Name string
+	// Firefox lists extension ids, chromium based browsers origins
+	Firefox bool
+	// linux and mac dirs relative to the home dir, and the windows registry key
+	LinuxDir    string
+	DarwinDir   string
+	RegistryKey string
+}
+
+var browsers = map[string]Browser{
+	"chrome": {
+		Name:        "chrome",
+		LinuxDir:    ".config/google-chrome/NativeMessagingHosts",
+		DarwinDir:   "Library/Application Support/Google/Chrome/NativeMessagingHosts",
+		RegistryKey: `HKCU\Software\Google\Chrome\NativeMessagingHosts`,
+	},
+	"chromium": {
+		Name:        "chromium",
+		LinuxDir:    ".config/chromium/NativeMessagingHosts",
+		DarwinDir:   "Library/Application Support/Chromium/NativeMessagingHosts",
+		RegistryKey: `HKCU\Software\Chromium\NativeMessagingHosts`,
+	},
+	"edge": {
+		Name:        "edge",
+		LinuxDir:    ".config/microsoft-edge/NativeMessagingHosts",
+		DarwinDir:   "Library/Application Support/Microsoft Edge/NativeMessagingHosts",
+		RegistryKey: `HKCU\Software\Microsoft\Edge\NativeMessagingHosts`,
+	},
+	"brave": {
+		Name:        "brave",
+		LinuxDir:    ".config/BraveSoftware/Brave-Browser/NativeMessagingHosts",
+		DarwinDir:   "Library/Application Support/BraveSoftware/Brave-Browser/NativeMessagingHosts",
+		RegistryKey: `HKCU\Software\BraveSoftware\Brave-Browser\NativeMessagingHosts`,
+	},
+	"firefox": {
+		Name:        "firefox",
+		Firefox:     true,
+		LinuxDir:    ".mozilla/native-messaging-hosts",
+		DarwinDir:   "Library/Application Support/Mozilla/NativeMessagingHosts",
+		RegistryKey: `HKCU\Software\Mozilla\NativeMessagingHosts`,
+	},
+}
+
+type manifestStruct struct {
+	Name              string   `json:"name"`
+	Description       string   `json:"description"`
+	Path              string   `json:"path"`
+	Type              string   `json:"type"`
+	AllowedOrigins    []string `json:"allowed_origins,omitempty"`
+	AllowedExtensions []string `json:"allowed_extensions,omitempty"`
+}
+
+func ListBrowsers() []string {
+	names := make([]string, 0, len(browsers))
+	for name := range browsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsLaunch reports whether the process was started by a browser as a
+// native messaging host. Chromium passes the caller origin, Firefox the
+// manifest path and the extension id.
+func IsLaunch(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	if strings.HasPrefix(args[0], "chrome-extension://") {
+		return true
+	}
+	return len(args) >= 2 && strings.HasSuffix(args[0], Name+".json")
+}
+
+// Install registers the running binary as the native host of one browser
+// for the given extension and returns where the manifest was written.
+// Browsers start the host without arguments of our own, so with homeArgs
+// the manifest points at a launcher script that adds them.
+func Install(browserName string, extensionID string, homeArgs []string) (string, error) {
+	browser, ok := browsers[browserName]
+	if !ok {
+		return "", fmt.Errorf("unknown browser %q, expected one of %s", browserName, strings.Join(ListBrowsers(), ", "))
+	}
+	extensionID = strings.TrimSpace(extensionID)
+	if extensionID == "" {
+		return "", fmt.Errorf("extension id is required")
+	}
+	executable, errExe := executablePath()
+	if errExe != nil {
+		return "", errExe
+	}
+	dir, errDir := manifestDir(browser)
+	if errDir != nil {
+		return "", errDir
+	}
+	if err := utils.CheckOrCreateDir(dir); err != nil {
+		return "", err
+	}
+	manifest := manifestStruct{
+		Name:        Name,
+		Description: "Send videos from the browser to ytdlp",
+		Path:        executable,
+		Type:        "stdio",
+	}
+	launcherPath := filepath.Join(dir, launcherFile)
+	if len(homeArgs) > 0 {
+		if err := utils.WriteFileAtomic(launcherPath, []byte(launcherScript(executable, homeArgs)), 0755); err != nil {
+			return "", err
+		}
+		manifest.Path = launcherPath
+	} else if err := os.Remove(launcherPath); err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	if browser.Firefox {
+		manifest.AllowedExtensions = []string{extensionID}
+	} else {
+		manifest.AllowedOrigins = []string{fmt.Sprintf("chrome-extension://%s/", extensionID)}
+	}
+	data, errJson := json.MarshalIndent(manifest, "", "  ")
+	if errJson != nil {
+		return "", errJson
+	}
+	manifestPath := filepath.Join(dir, Name+".json")
+	if err := utils.WriteFileAtomic(manifestPath, data, 0644); err != nil {
+		return "", err
+	}
+	if err := register(browser, manifestPath); err != nil {
+		return "", err
+	}
+	return manifestPath, nil
+}
+
+// Reinstall writes the manifests that start the running binary again with
+// homeArgs, after the home dir moved from from to to. It returns the
+// browsers it updated.
+func Reinstall(from string, to string, homeArgs []string) ([]string, error) {
+	executable, errExe := executablePath()
+	if errExe != nil {
+		return nil, errExe
+	}
+	var updated []string
+	for _, name := range ListBrowsers() {
+		manifestPath := registeredManifest(browsers[name])
+		if manifestPath == "" {
+			continue
+		}
+		data, err := os.ReadFile(manifestPath)
+		if os.IsNotExist(err) {
+			// a manifest kept in the home dir moved along with it
+			if rel, errRel := filepath.Rel(from, manifestPath); errRel == nil && !strings.HasPrefix(rel, "..") {
+				manifestPath = filepath.Join(to, rel)
+				data, err = os.ReadFile(manifestPath)
+			}
+		}
+		if err != nil {
+			continue
+		}
+		var manifest manifestStruct
+		if json.Unmarshal(data, &manifest) != nil {
+			continue
+		}
+		if manifest.Path != executable && manifest.Path != filepath.Join(filepath.Dir(manifestPath), launcherFile) {
+			continue
+		}
+		extensionID := ""
+		if len(manifest.AllowedExtensions) > 0 {
+			extensionID = manifest.AllowedExtensions[0]
+		} else if len(manifest.AllowedOrigins) > 0 {
+			extensionID = strings.TrimSuffix(strings.TrimPrefix(manifest.AllowedOrigins[0], "chrome-extension://"), "/")
+		}
+		if _, err := Install(name, extensionID, homeArgs); err != nil {
+			return updated, err
+		}
+		updated = append(updated, name)
+	}
+	return updated, nil
+}
+
+func executablePath() (string, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	if resolved, errLink := filepath.EvalSymlinks(executable); errLink == nil {
+		executable = resolved
+	}
+	return executable, nil
+}
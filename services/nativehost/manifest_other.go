@@ -0,0 +1,50 @@
+//go:build !windows
+
+package nativehost
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const launcherFile = Name + ".sh"
+
+func manifestDir(browser Browser) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS == "darwin" {
+		return filepath.Join(home, browser.DarwinDir), nil
+	}
+	return filepath.Join(home, browser.LinuxDir), nil
+}
+
+// registeredManifest is where the browser looks for the manifest, empty
+// when it cannot be found.
+func registeredManifest(browser Browser) string {
+	dir, err := manifestDir(browser)
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, Name+".json")
+}
+
+func register(browser Browser, manifestPath string) error {
+	return nil
+}
+
+// launcherScript starts executable with args and whatever the browser passes.
+func launcherScript(executable string, args []string) string {
+	quoted := []string{shellQuote(executable)}
+	for _, arg := range args {
+		quoted = append(quoted, shellQuote(arg))
+	}
+	return "#!/bin/sh\nexec " + strings.Join(quoted, " ") + " \"$@\"\n"
+}
+
+func shellQuote(arg string) string {
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
@@ -0,0 +1,69 @@
+package nativehost
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func readManifest(t *testing.T, manifestPath string) manifestStruct {
+	t.Helper()
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest manifestStruct
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	return manifest
+}
+
+func TestInstallLauncher(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("manifests are registered in the registry")
+	}
+	t.Setenv("HOME", t.TempDir())
+	executable, err := executablePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	home := filepath.Join(t.TempDir(), "it's data")
+
+	manifestPath, errInstall := Install("chrome", "abc", []string{"--home", home})
+	if errInstall != nil {
+		t.Fatalf("Install() = %v", errInstall)
+	}
+	launcherPath := filepath.Join(filepath.Dir(manifestPath), launcherFile)
+	manifest := readManifest(t, manifestPath)
+	if manifest.Path != launcherPath || !reflect.DeepEqual(manifest.AllowedOrigins, []string{"chrome-extension://abc/"}) {
+		t.Errorf("manifest = %+v, want the launcher to be started for abc", manifest)
+	}
+	script, errRead := os.ReadFile(launcherPath)
+	if errRead != nil {
+		t.Fatal(errRead)
+	}
+	if want := " '--home' '" + filepath.Dir(home) + `/it'\''s data' "$@"`; !strings.HasPrefix(string(script), "#!/bin/sh\nexec '"+executable+"'") || !strings.Contains(string(script), want) {
+		t.Errorf("launcher = %q, want it to start %s on %s", script, executable, home)
+	}
+	if info, errStat := os.Stat(launcherPath); errStat != nil || info.Mode()&0100 == 0 {
+		t.Errorf("launcher is not executable: %v", errStat)
+	}
+
+	// the home dir moved back to the default, the launcher is not needed
+	updated, errReinstall := Reinstall(home, filepath.Join(t.TempDir(), "moved"), nil)
+	if errReinstall != nil || !reflect.DeepEqual(updated, []string{"chrome"}) {
+		t.Fatalf("Reinstall() = %v, %v, want chrome", updated, errReinstall)
+	}
+	manifest = readManifest(t, manifestPath)
+	if manifest.Path != executable || !reflect.DeepEqual(manifest.AllowedOrigins, []string{"chrome-extension://abc/"}) {
+		t.Errorf("manifest = %+v, want %s to be started for abc", manifest, executable)
+	}
+	if _, errStat := os.Stat(launcherPath); !os.IsNotExist(errStat) {
+		t.Errorf("launcher left behind: %v", errStat)
+	}
+}
@@ -0,0 +1,64 @@
+package nativehost
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"ytdlp/utils"
+)
+
+const launcherFile = Name + ".bat"
+
+// manifestDir sits outside the home dir, the registry keeps pointing at it
+// when the home dir moves.
+func manifestDir(browser Browser) (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "ytdlp", "native-messaging", browser.Name), nil
+}
+
+// registeredManifest is the manifest the registry points the browser at,
+// empty when the host is not registered.
+func registeredManifest(browser Browser) string {
+	cmd := exec.Command("reg", "query", browser.RegistryKey+`\`+Name, "/ve")
+	utils.HideWindow(cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		if _, value, ok := strings.Cut(line, "REG_SZ"); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// register points the browser at the manifest, windows browsers look hosts
+// up in the registry instead of a folder.
+func register(browser Browser, manifestPath string) error {
+	cmd := exec.Command("reg", "add", browser.RegistryKey+`\`+Name, "/ve", "/t", "REG_SZ", "/d", manifestPath, "/f")
+	utils.HideWindow(cmd)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("register native host: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// launcherScript starts executable with args and whatever the browser
+// passes, a % in a path has to be doubled in a batch file.
+func launcherScript(executable string, args []string) string {
+	quoted := []string{batchQuote(executable)}
+	for _, arg := range args {
+		quoted = append(quoted, batchQuote(arg))
+	}
+	return "@echo off\r\n" + strings.Join(quoted, " ") + " %*\r\n"
+}
+
+func batchQuote(arg string) string {
+	return `"` + strings.ReplaceAll(arg, "%", "%%") + `"`
+}
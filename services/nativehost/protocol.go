@@ -0,0 +1,48 @@
+package nativehost
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// browsers refuse messages from the host bigger than 1 MB
+	maxOutgoing = 1 << 20
+	// requests carry a url and the cookies of one site, anything bigger is junk
+	maxIncoming = 8 << 20
+)
+
+// ReadMessage reads one message framed by the browser: a 32-bit length in
+// native byte order, little endian on every supported platform, then json.
+func ReadMessage(r io.Reader, value interface{}) error {
+	var length uint32
+	if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+		return err
+	}
+	if length > maxIncoming {
+		return fmt.Errorf("message of %d bytes is too large", length)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
+
+func WriteMessage(w io.Writer, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if len(data) > maxOutgoing {
+		return errors.New("response is too large")
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(data))); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
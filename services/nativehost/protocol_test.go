@@ -0,0 +1,77 @@
+package nativehost
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func frame(payload string) []byte {
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(payload)))
+	buf.WriteString(payload)
+	return buf.Bytes()
+}
+
+func TestReadMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   []byte
+		want    Request
+		wantErr error
+	}{
+		{name: "request", input: frame(`{"type":"download","url":"https://x"}`), want: Request{Type: "download", Url: "https://x"}},
+		{name: "empty stream", input: nil, wantErr: io.EOF},
+		{name: "short length", input: []byte{1, 0}, wantErr: io.ErrUnexpectedEOF},
+		{name: "short body", input: frame(`{"type":"ping"}`)[:8], wantErr: io.ErrUnexpectedEOF},
+		{name: "too large", input: []byte{0xff, 0xff, 0xff, 0xff}},
+		{name: "not json", input: frame(`nope`)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got Request
+			err := ReadMessage(bytes.NewReader(test.input), &got)
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Fatalf("ReadMessage() error = %v, want %v", err, test.wantErr)
+				}
+				return
+			}
+			if test.want.Type == "" {
+				if err == nil {
+					t.Fatal("ReadMessage() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Type != test.want.Type || got.Url != test.want.Url {
+				t.Errorf("ReadMessage() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestWriteMessage(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMessage(&buf, Response{Ok: true, TaskID: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"ok":true,"taskId":"1"}`
+	if got := buf.Bytes(); !bytes.Equal(got, frame(want)) {
+		t.Errorf("WriteMessage() = %q, want %q", got, frame(want))
+	}
+
+	var back Response
+	if err := ReadMessage(&buf, &back); err != nil || !back.Ok || back.TaskID != "1" {
+		t.Errorf("ReadMessage() of the written message = %+v, %v", back, err)
+	}
+
+	if err := WriteMessage(io.Discard, Response{Error: strings.Repeat("x", maxOutgoing)}); err == nil {
+		t.Error("WriteMessage() of a message over 1 MB succeeded, want an error")
+	}
+}
@@ -0,0 +1,214 @@
+package podcast
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"ytdlp/services/subscription"
+	ytdlp "ytdlp/services/yt-dlp"
+	"ytdlp/utils"
+)
+
+const (
+	itunesNamespace = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+)
+
+type infoJson struct {
+	ID          string  `json:"id"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Duration    float64 `json:"duration"`
+	UploadDate  string  `json:"upload_date"`
+	Timestamp   int64   `json:"timestamp"`
+	WebpageUrl  string  `json:"webpage_url"`
+	Uploader    string  `json:"uploader"`
+}
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Itunes  string     `xml:"xmlns:itunes,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string       `xml:"title"`
+	Link        string       `xml:"link"`
+	Description string       `xml:"description"`
+	Language    string       `xml:"language,omitempty"`
+	Author      string       `xml:"itunes:author,omitempty"`
+	Image       *itunesImage `xml:"itunes:image,omitempty"`
+	Explicit    string       `xml:"itunes:explicit"`
+	Items       []rssItem    `xml:"item"`
+}
+
+type itunesImage struct {
+	Href string `xml:"href,attr"`
+}
+
+type rssItem struct {
+	Title       string       `xml:"title"`
+	Description string       `xml:"description"`
+	Link        string       `xml:"link,omitempty"`
+	Guid        rssGuid      `xml:"guid"`
+	PubDate     string       `xml:"pubDate,omitempty"`
+	Enclosure   rssEnclosure `xml:"enclosure"`
+	Duration    string       `xml:"itunes:duration,omitempty"`
+	Image       *itunesImage `xml:"itunes:image,omitempty"`
+	Summary     string       `xml:"itunes:summary,omitempty"`
+}
+
+type rssGuid struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	Url    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type episode struct {
+	info      infoJson
+	audioFile string
+	size      int64
+	image     string
+	published time.Time
+}
+
+func GetPodcastDir() string {
+	return filepath.Join(utils.GetHomeDir(), "podcasts")
+}
+
+func GetFeedDir(subscriptionID string) string {
+	return filepath.Join(GetPodcastDir(), subscriptionID)
+}
+
+// OutputTemplate keeps the audio, info json and artwork of an entry side by
+// side so the feed can be rebuilt from the folder alone.
+func OutputTemplate(subscriptionID string) string {
+	return filepath.Join(GetFeedDir(subscriptionID), "%(id)s.%(ext)s")
+}
+
+// BuildFeed renders an RSS 2.0 feed with iTunes tags for the episodes
+// downloaded so far, file urls are relative to baseUrl.
+func BuildFeed(sub subscription.Subscription, baseUrl string) ([]byte, error) {
+	episodes, err := loadEpisodes(sub.ID)
+	if err != nil {
+		return nil, err
+	}
+	filesUrl := fmt.Sprintf("%s/files/%s/", strings.TrimRight(baseUrl, "/"), url.PathEscape(sub.ID))
+	channel := rssChannel{
+		Title:       sub.Name,
+		Link:        sub.Url,
+		Description: fmt.Sprintf("Audio downloaded from %s", sub.Url),
+		Explicit:    "false",
+		Items:       make([]rssItem, 0, len(episodes)),
+	}
+	for _, ep := range episodes {
+		item := rssItem{
+			Title:       ep.info.Title,
+			Description: ep.info.Description,
+			Summary:     ep.info.Description,
+			Link:        ep.info.WebpageUrl,
+			Guid:        rssGuid{IsPermaLink: "false", Value: ep.info.ID},
+			Enclosure: rssEnclosure{
+				Url:    filesUrl + url.PathEscape(filepath.Base(ep.audioFile)),
+				Length: ep.size,
+				Type:   audioType(ep.audioFile),
+			},
+		}
+		if !ep.published.IsZero() {
+			item.PubDate = ep.published.Format(time.RFC1123Z)
+		}
+		if ep.info.Duration > 0 {
+			item.Duration = ytdlp.FormatTimestamp(ep.info.Duration)
+		}
+		if ep.image != "" {
+			item.Image = &itunesImage{Href: filesUrl + url.PathEscape(filepath.Base(ep.image))}
+			if channel.Image == nil {
+				channel.Image = item.Image
+			}
+		}
+		if channel.Author == "" {
+			channel.Author = ep.info.Uploader
+		}
+		channel.Items = append(channel.Items, item)
+	}
+	output, err := xml.MarshalIndent(rss{Version: "2.0", Itunes: itunesNamespace, Channel: channel}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), output...), nil
+}
+
+// loadEpisodes pairs every info json with its audio file, newest first.
+func loadEpisodes(subscriptionID string) ([]episode, error) {
+	dir := GetFeedDir(subscriptionID)
+	infoFiles, err := filepath.Glob(filepath.Join(dir, "*.info.json"))
+	if err != nil {
+		return nil, err
+	}
+	episodes := make([]episode, 0, len(infoFiles))
+	for _, infoFile := range infoFiles {
+		data, errRead := os.ReadFile(infoFile)
+		if errRead != nil {
+			continue
+		}
+		var info infoJson
+		if errJson := json.Unmarshal(data, &info); errJson != nil {
+			continue
+		}
+		base := strings.TrimSuffix(infoFile, ".info.json")
+		ep := episode{info: info}
+		siblings, _ := filepath.Glob(base + ".*")
+		for _, sibling := range siblings {
+			ext := strings.ToLower(filepath.Ext(sibling))
+			switch {
+			case strings.HasSuffix(sibling, ".info.json"):
+			case ext == ".jpg" || ext == ".jpeg" || ext == ".png" || ext == ".webp":
+				ep.image = sibling
+			case strings.HasPrefix(audioType(sibling), "audio/"):
+				ep.audioFile = sibling
+			}
+		}
+		if ep.audioFile == "" {
+			// still downloading or converting
+			continue
+		}
+		if size, errSize := utils.GetFileSize(ep.audioFile); errSize == nil {
+			ep.size = size
+		}
+		if info.Timestamp > 0 {
+			ep.published = time.Unix(info.Timestamp, 0)
+		} else if t, errDate := time.Parse("20060102", info.UploadDate); errDate == nil {
+			ep.published = t
+		}
+		episodes = append(episodes, ep)
+	}
+	sort.Slice(episodes, func(i, j int) bool {
+		return episodes[i].published.After(episodes[j].published)
+	})
+	return episodes, nil
+}
+
+func audioType(filePath string) string {
+	switch ext := strings.ToLower(filepath.Ext(filePath)); ext {
+	case ".mp3":
+		return "audio/mpeg"
+	case ".m4a", ".aac":
+		return "audio/mp4"
+	case ".opus", ".ogg":
+		return "audio/ogg"
+	default:
+		return mime.TypeByExtension(ext)
+	}
+}
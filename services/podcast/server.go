@@ -0,0 +1,132 @@
+package podcast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"ytdlp/helpers/logrus"
+	"ytdlp/services/subscription"
+	ytdlp "ytdlp/services/yt-dlp"
+	"ytdlp/utils"
+)
+
+// Lookup returns the subscription behind a feed id.
+type Lookup func(id string) (subscription.Subscription, bool)
+
+// Server serves the feeds and the audio files on the LAN so podcast apps on
+// other devices can subscribe.
+type Server struct {
+	ctx    *context.Context
+	lookup Lookup
+
+	mu      sync.Mutex
+	server  *http.Server
+	baseUrl string
+}
+
+func NewServer(ctx *context.Context, lookup Lookup) *Server {
+	return &Server{
+		ctx:    ctx,
+		lookup: lookup,
+	}
+}
+
+func (s *Server) Start(port int) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.server != nil {
+		return s.baseUrl, nil
+	}
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return "", err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/feeds/", s.handleFeed)
+	mux.HandleFunc("/files/", s.handleFile)
+	s.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	s.baseUrl = fmt.Sprintf("http://%s:%d", utils.GetLanIP(), listener.Addr().(*net.TCPAddr).Port)
+	go func(server *http.Server) {
+		if errServe := server.Serve(listener); errServe != nil && !errors.Is(errServe, http.ErrServerClosed) {
+			logrus.LogrusLoggerWithContext(s.ctx).Error(errServe.Error())
+		}
+	}(s.server)
+	logrus.LogrusLoggerWithContext(s.ctx).Infof("Podcast server listening on %s", s.baseUrl)
+	return s.baseUrl, nil
+}
+
+func (s *Server) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.server == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := s.server.Shutdown(ctx)
+	s.server = nil
+	s.baseUrl = ""
+	return err
+}
+
+func (s *Server) BaseUrl() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.baseUrl
+}
+
+func (s *Server) FeedUrl(subscriptionID string) string {
+	baseUrl := s.BaseUrl()
+	if baseUrl == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/feeds/%s.xml", baseUrl, subscriptionID)
+}
+
+func (s *Server) handleFeed(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/feeds/"), ".xml")
+	sub, ok := s.audioSubscription(id)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	feed, err := BuildFeed(sub, s.BaseUrl())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	_, _ = w.Write(feed)
+}
+
+func (s *Server) handleFile(w http.ResponseWriter, r *http.Request) {
+	id, name, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/files/"), "/")
+	if !ok || name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		http.NotFound(w, r)
+		return
+	}
+	if _, found := s.audioSubscription(id); !found {
+		http.NotFound(w, r)
+		return
+	}
+	// ServeFile answers range requests, podcast apps rely on them for seeking
+	http.ServeFile(w, r, filepath.Join(GetFeedDir(id), name))
+}
+
+func (s *Server) audioSubscription(id string) (subscription.Subscription, bool) {
+	sub, ok := s.lookup(id)
+	if !ok {
+		return sub, false
+	}
+	preset, err := ytdlp.GetPreset(sub.Preset)
+	return sub, err == nil && preset.AudioOnly
+}
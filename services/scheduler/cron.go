@@ -0,0 +1,137 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var cronShortcuts = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@nightly": "0 2 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// Cron is a standard five-field expression: minute hour day-of-month month
+// day-of-week. Fields accept *, lists, ranges and steps like "1-5" or "*/15".
+type Cron struct {
+	minutes  [60]bool
+	hours    [24]bool
+	days     [32]bool
+	months   [13]bool
+	weekdays [7]bool
+	// day-of-month and day-of-week are OR-ed when both are restricted
+	anyDay     bool
+	anyWeekday bool
+}
+
+func ParseCron(expr string) (*Cron, error) {
+	expr = strings.TrimSpace(expr)
+	if shortcut, ok := cronShortcuts[expr]; ok {
+		expr = shortcut
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron %q must have 5 fields", expr)
+	}
+	c := &Cron{
+		anyDay:     fields[2] == "*",
+		anyWeekday: fields[4] == "*",
+	}
+	if err := parseCronField(fields[0], 0, 59, c.minutes[:]); err != nil {
+		return nil, fmt.Errorf("cron minute: %s", err.Error())
+	}
+	if err := parseCronField(fields[1], 0, 23, c.hours[:]); err != nil {
+		return nil, fmt.Errorf("cron hour: %s", err.Error())
+	}
+	if err := parseCronField(fields[2], 1, 31, c.days[:]); err != nil {
+		return nil, fmt.Errorf("cron day of month: %s", err.Error())
+	}
+	if err := parseCronField(fields[3], 1, 12, c.months[:]); err != nil {
+		return nil, fmt.Errorf("cron month: %s", err.Error())
+	}
+	weekdays := make([]bool, 8)
+	if err := parseCronField(fields[4], 0, 7, weekdays); err != nil {
+		return nil, fmt.Errorf("cron day of week: %s", err.Error())
+	}
+	// both 0 and 7 mean sunday
+	copy(c.weekdays[:], weekdays[:7])
+	c.weekdays[0] = c.weekdays[0] || weekdays[7]
+	return c, nil
+}
+
+func parseCronField(field string, min int, max int, target []bool) error {
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			value, err := strconv.Atoi(stepPart)
+			if err != nil || value <= 0 {
+				return fmt.Errorf("invalid step %q", part)
+			}
+			step = value
+		}
+		from, to := min, max
+		if rangePart != "*" {
+			start, end, isRange := strings.Cut(rangePart, "-")
+			value, err := strconv.Atoi(start)
+			if err != nil {
+				return fmt.Errorf("invalid value %q", part)
+			}
+			from, to = value, value
+			if isRange {
+				if to, err = strconv.Atoi(end); err != nil {
+					return fmt.Errorf("invalid range %q", part)
+				}
+			} else if hasStep {
+				to = max
+			}
+		}
+		if from < min || to > max || from > to {
+			return fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+		for i := from; i <= to; i += step {
+			target[i] = true
+		}
+	}
+	return nil
+}
+
+// Next returns the first matching minute strictly after t, or the zero time
+// when nothing matches within five years.
+func (c *Cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if !c.months[t.Month()] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.hours[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.minutes[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (c *Cron) matchDay(t time.Time) bool {
+	day := c.days[t.Day()]
+	weekday := c.weekdays[t.Weekday()]
+	if c.anyDay || c.anyWeekday {
+		return day && weekday
+	}
+	return day || weekday
+}
@@ -0,0 +1,84 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"1-x * * * *",
+		"@yearly",
+	}
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := ParseCron(expr); err == nil {
+				t.Errorf("ParseCron(%q) succeeded, want an error", expr)
+			}
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	at := func(value string) time.Time {
+		parsed, err := time.ParseInLocation("2006-01-02 15:04", value, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+	tests := []struct {
+		name  string
+		expr  string
+		after string
+		want  string
+	}{
+		{name: "next minute, seconds dropped", expr: "* * * * *", after: "2024-05-01 10:00", want: "2024-05-01 10:01"},
+		{name: "step", expr: "*/15 * * * *", after: "2024-05-01 10:16", want: "2024-05-01 10:30"},
+		{name: "step wraps the hour", expr: "*/15 * * * *", after: "2024-05-01 10:50", want: "2024-05-01 11:00"},
+		{name: "range with step", expr: "10-40/10 * * * *", after: "2024-05-01 10:41", want: "2024-05-01 11:10"},
+		{name: "value with step runs to the max", expr: "50/5 * * * *", after: "2024-05-01 10:52", want: "2024-05-01 10:55"},
+		{name: "list", expr: "0 8,20 * * *", after: "2024-05-01 09:00", want: "2024-05-01 20:00"},
+		{name: "daily shortcut", expr: "@daily", after: "2024-05-01 09:00", want: "2024-05-02 00:00"},
+		{name: "nightly shortcut", expr: "@nightly", after: "2024-05-01 01:59", want: "2024-05-01 02:00"},
+		{name: "weekday range", expr: "0 9 * * 1-5", after: "2024-05-03 10:00", want: "2024-05-06 09:00"},
+		{name: "seven is sunday", expr: "0 9 * * 7", after: "2024-05-01 10:00", want: "2024-05-05 09:00"},
+		{name: "day of month and weekday are or-ed", expr: "0 0 15 * 1", after: "2024-05-07 00:00", want: "2024-05-13 00:00"},
+		{name: "day of month with any weekday", expr: "0 0 31 * *", after: "2024-04-01 00:00", want: "2024-05-31 00:00"},
+		{name: "month rolls over the year", expr: "0 0 1 1 *", after: "2024-05-01 00:00", want: "2025-01-01 00:00"},
+		{name: "leap day", expr: "0 12 29 2 *", after: "2025-03-01 00:00", want: "2028-02-29 12:00"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := ParseCron(test.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := c.Next(at(test.after).Add(30 * time.Second))
+			if want := at(test.want); !got.Equal(want) {
+				t.Errorf("Next(%s) = %s, want %s", test.after, got, want)
+			}
+		})
+	}
+}
+
+func TestCronNextNever(t *testing.T) {
+	c, err := ParseCron("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Next(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)); !got.IsZero() {
+		t.Errorf("Next() = %s, want the zero time for february 30", got)
+	}
+}
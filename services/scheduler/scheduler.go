@@ -0,0 +1,283 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"ytdlp/helpers/logrus"
+	ytdlp "ytdlp/services/yt-dlp"
+	"ytdlp/utils"
+)
+
+type Action string
+
+const (
+	ActionDownload Action = "download"
+	ActionRecord   Action = "record"
+)
+
+const (
+	checkInterval = 30 * time.Second
+)
+
+type Schedule struct {
+	ID     string              `json:"id"`
+	Name   string              `json:"name"`
+	Action Action              `json:"action"`
+	Url    string              `json:"url"`
+	Split  ytdlp.SplitState    `json:"split"`
+	Record ytdlp.RecordOptions `json:"record"`
+	// At is a one-off RFC 3339 run time, Cron a recurring expression
+	At        string    `json:"at,omitempty"`
+	Cron      string    `json:"cron,omitempty"`
+	Paused    bool      `json:"paused"`
+	NextRun   time.Time `json:"nextRun"`
+	LastRun   time.Time `json:"lastRun"`
+	LastError string    `json:"lastError"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Runner hands a due schedule to the download pipeline.
+type Runner func(schedule Schedule) error
+
+type Scheduler struct {
+	ctx       *context.Context
+	runner    Runner
+	storePath string
+
+	mu        sync.Mutex
+	schedules map[string]*Schedule
+	// saveMu covers the snapshot and the write, so a save started later never
+	// gets overwritten by an older snapshot
+	saveMu sync.Mutex
+}
+
+func NewScheduler(ctx *context.Context, runner Runner) *Scheduler {
+	return &Scheduler{
+		ctx:       ctx,
+		runner:    runner,
+		storePath: filepath.Join(utils.GetHomeDir(), "schedules.json"),
+		schedules: map[string]*Schedule{},
+	}
+}
+
+func (s *Scheduler) Load() error {
+	data, errRead := os.ReadFile(s.storePath)
+	if os.IsNotExist(errRead) {
+		return nil
+	}
+	if errRead != nil {
+		return errRead
+	}
+	schedules := make([]*Schedule, 0)
+	if err := json.Unmarshal(data, &schedules); err != nil {
+		return fmt.Errorf("read schedules: %s", err.Error())
+	}
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, schedule := range schedules {
+		// recurring runs missed while the app was closed are skipped, a missed
+		// one-off run still fires once on the next check
+		if schedule.Cron != "" && !schedule.NextRun.IsZero() && schedule.NextRun.Before(now) {
+			schedule.NextRun = nextRun(schedule, now)
+		}
+		s.schedules[schedule.ID] = schedule
+	}
+	return nil
+}
+
+// Start checks for due schedules until the context is done.
+func (s *Scheduler) Start() {
+	go func() {
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+		s.runDue(time.Now())
+		for {
+			select {
+			case <-(*s.ctx).Done():
+				return
+			case now := <-ticker.C:
+				s.runDue(now)
+			}
+		}
+	}()
+}
+
+func (s *Scheduler) Add(schedule Schedule) (Schedule, error) {
+	if err := validate(&schedule); err != nil {
+		return Schedule{}, err
+	}
+	now := time.Now()
+	schedule.ID = utils.GenerateSessionID()
+	schedule.CreatedAt = now
+	schedule.LastRun = time.Time{}
+	schedule.LastError = ""
+	schedule.NextRun = nextRun(&schedule, now)
+	if schedule.NextRun.IsZero() {
+		return Schedule{}, errors.New("schedule never runs")
+	}
+	s.mu.Lock()
+	s.schedules[schedule.ID] = &schedule
+	s.mu.Unlock()
+	return schedule, s.save()
+}
+
+func (s *Scheduler) List() []Schedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	schedules := make([]Schedule, 0, len(s.schedules))
+	for _, schedule := range s.schedules {
+		schedules = append(schedules, *schedule)
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].CreatedAt.Before(schedules[j].CreatedAt)
+	})
+	return schedules
+}
+
+func (s *Scheduler) Pause(id string) error {
+	return s.update(id, func(schedule *Schedule) {
+		schedule.Paused = true
+	})
+}
+
+func (s *Scheduler) Resume(id string) error {
+	return s.update(id, func(schedule *Schedule) {
+		schedule.Paused = false
+		if schedule.Cron != "" {
+			schedule.NextRun = nextRun(schedule, time.Now())
+		}
+	})
+}
+
+func (s *Scheduler) Delete(id string) error {
+	s.mu.Lock()
+	if _, ok := s.schedules[id]; !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("schedule %s not found", id)
+	}
+	delete(s.schedules, id)
+	s.mu.Unlock()
+	return s.save()
+}
+
+func (s *Scheduler) update(id string, fn func(schedule *Schedule)) error {
+	s.mu.Lock()
+	schedule, ok := s.schedules[id]
+	if !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("schedule %s not found", id)
+	}
+	fn(schedule)
+	s.mu.Unlock()
+	return s.save()
+}
+
+func (s *Scheduler) runDue(now time.Time) {
+	due := make([]Schedule, 0)
+	s.mu.Lock()
+	for _, schedule := range s.schedules {
+		if schedule.Paused || schedule.NextRun.IsZero() || schedule.NextRun.After(now) {
+			continue
+		}
+		schedule.LastRun = now
+		// one-off schedules are done once fired
+		schedule.NextRun = time.Time{}
+		if schedule.Cron != "" {
+			schedule.NextRun = nextRun(schedule, now)
+		}
+		due = append(due, *schedule)
+	}
+	s.mu.Unlock()
+	if len(due) == 0 {
+		return
+	}
+	if err := s.save(); err != nil {
+		logrus.LogrusLoggerWithContext(s.ctx).Error(err.Error())
+	}
+	for _, schedule := range due {
+		go s.run(schedule)
+	}
+}
+
+func (s *Scheduler) run(schedule Schedule) {
+	logrus.LogrusLoggerWithContext(s.ctx).Infof("Running schedule %s (%s %s)", schedule.ID, schedule.Action, schedule.Url)
+	errRun := s.runner(schedule)
+	lastError := ""
+	if errRun != nil {
+		lastError = errRun.Error()
+		logrus.LogrusLoggerWithContext(s.ctx).Errorf("Schedule %s failed: %s", schedule.ID, lastError)
+	}
+	if err := s.update(schedule.ID, func(stored *Schedule) {
+		stored.LastError = lastError
+	}); err != nil {
+		logrus.LogrusLoggerWithContext(s.ctx).Warn(err.Error())
+	}
+}
+
+func (s *Scheduler) save() error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+	schedules := s.List()
+	data, err := json.MarshalIndent(schedules, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(s.storePath, data, 0644)
+}
+
+func validate(schedule *Schedule) error {
+	schedule.Url = strings.TrimSpace(schedule.Url)
+	if schedule.Url == "" {
+		return errors.New("schedule url is required")
+	}
+	switch schedule.Action {
+	case ActionDownload, ActionRecord:
+	case "":
+		schedule.Action = ActionDownload
+	default:
+		return fmt.Errorf("unknown schedule action %q", schedule.Action)
+	}
+	if (schedule.At == "") == (schedule.Cron == "") {
+		return errors.New("schedule needs either a run time or a cron expression")
+	}
+	if schedule.At != "" {
+		if _, err := time.Parse(time.RFC3339, schedule.At); err != nil {
+			return fmt.Errorf("invalid run time %q", schedule.At)
+		}
+	}
+	if schedule.Cron != "" {
+		if _, err := ParseCron(schedule.Cron); err != nil {
+			return err
+		}
+	}
+	if schedule.Action == ActionRecord && schedule.Record.StopAt != "" {
+		if _, err := ytdlp.ParseStopAt(schedule.Record.StopAt, time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func nextRun(schedule *Schedule, after time.Time) time.Time {
+	if schedule.At != "" {
+		at, err := time.Parse(time.RFC3339, schedule.At)
+		if err != nil {
+			return time.Time{}
+		}
+		return at
+	}
+	cron, err := ParseCron(schedule.Cron)
+	if err != nil {
+		return time.Time{}
+	}
+	return cron.Next(after)
+}
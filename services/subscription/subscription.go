@@ -0,0 +1,352 @@
+package subscription
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"ytdlp/helpers/logrus"
+	ytdlp "ytdlp/services/yt-dlp"
+	"ytdlp/utils"
+	"ytdlp/utils/emit"
+)
+
+const (
+	pollInterval           = time.Minute
+	defaultIntervalMinutes = 60
+)
+
+type Filters struct {
+	TitleRegex  string  `json:"titleRegex"`
+	MinDuration float64 `json:"minDuration"`
+	MaxDuration float64 `json:"maxDuration"`
+	// DateAfter is YYYYMMDD like yt-dlp --dateafter
+	DateAfter string `json:"dateAfter"`
+}
+
+type Subscription struct {
+	ID              string  `json:"id"`
+	Name            string  `json:"name"`
+	Url             string  `json:"url"`
+	Preset          string  `json:"preset"`
+	Filters         Filters `json:"filters"`
+	IntervalMinutes int     `json:"intervalMinutes"`
+	// Backfill downloads the entries already published when subscribing,
+	// otherwise they are marked as seen and only new uploads are fetched
+	Backfill  bool      `json:"backfill"`
+	Seeded    bool      `json:"seeded"`
+	Paused    bool      `json:"paused"`
+	LastCheck time.Time `json:"lastCheck"`
+	LastError string    `json:"lastError"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Enqueuer hands a new entry to the download queue, it returns false when
+// the entry is already queued.
+type Enqueuer func(subscription Subscription, entry ytdlp.Entry) bool
+
+type Poller struct {
+	ctx       *context.Context
+	enqueue   Enqueuer
+	storePath string
+
+	mu            sync.Mutex
+	subscriptions map[string]*Subscription
+	checking      map[string]bool
+}
+
+func NewPoller(ctx *context.Context, enqueue Enqueuer) *Poller {
+	return &Poller{
+		ctx:           ctx,
+		enqueue:       enqueue,
+		storePath:     filepath.Join(utils.GetHomeDir(), "subscriptions.json"),
+		subscriptions: map[string]*Subscription{},
+		checking:      map[string]bool{},
+	}
+}
+
+func (p *Poller) Load() error {
+	data, errRead := os.ReadFile(p.storePath)
+	if os.IsNotExist(errRead) {
+		return nil
+	}
+	if errRead != nil {
+		return errRead
+	}
+	subscriptions := make([]*Subscription, 0)
+	if err := json.Unmarshal(data, &subscriptions); err != nil {
+		return fmt.Errorf("read subscriptions: %s", err.Error())
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, subscription := range subscriptions {
+		p.subscriptions[subscription.ID] = subscription
+	}
+	return nil
+}
+
+// Start checks every due subscription until the context is done.
+func (p *Poller) Start() {
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		p.checkDue(time.Now())
+		for {
+			select {
+			case <-(*p.ctx).Done():
+				return
+			case now := <-ticker.C:
+				p.checkDue(now)
+			}
+		}
+	}()
+}
+
+func (p *Poller) Add(subscription Subscription) (Subscription, error) {
+	if err := validate(&subscription); err != nil {
+		return Subscription{}, err
+	}
+	subscription.ID = utils.GenerateSessionID()
+	subscription.CreatedAt = time.Now()
+	subscription.LastCheck = time.Time{}
+	subscription.LastError = ""
+	subscription.Seeded = false
+	p.mu.Lock()
+	p.subscriptions[subscription.ID] = &subscription
+	p.mu.Unlock()
+	if err := p.save(); err != nil {
+		return Subscription{}, err
+	}
+	go p.Check(subscription.ID)
+	return subscription, nil
+}
+
+func (p *Poller) Get(id string) (Subscription, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	subscription, ok := p.subscriptions[id]
+	if !ok {
+		return Subscription{}, false
+	}
+	return *subscription, true
+}
+
+func (p *Poller) List() []Subscription {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	subscriptions := make([]Subscription, 0, len(p.subscriptions))
+	for _, subscription := range p.subscriptions {
+		subscriptions = append(subscriptions, *subscription)
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].CreatedAt.Before(subscriptions[j].CreatedAt)
+	})
+	return subscriptions
+}
+
+func (p *Poller) SetPaused(id string, paused bool) error {
+	p.mu.Lock()
+	subscription, ok := p.subscriptions[id]
+	if !ok {
+		p.mu.Unlock()
+		return fmt.Errorf("subscription %s not found", id)
+	}
+	subscription.Paused = paused
+	p.mu.Unlock()
+	return p.save()
+}
+
+func (p *Poller) Delete(id string) error {
+	p.mu.Lock()
+	if _, ok := p.subscriptions[id]; !ok {
+		p.mu.Unlock()
+		return fmt.Errorf("subscription %s not found", id)
+	}
+	delete(p.subscriptions, id)
+	p.mu.Unlock()
+	return p.save()
+}
+
+func (p *Poller) checkDue(now time.Time) {
+	for _, subscription := range p.List() {
+		interval := time.Duration(subscription.IntervalMinutes) * time.Minute
+		if subscription.Paused || now.Sub(subscription.LastCheck) < interval {
+			continue
+		}
+		go p.Check(subscription.ID)
+	}
+}
+
+// Check lists the subscription, compares it with the download archive and
+// enqueues every new entry that passes the filters.
+func (p *Poller) Check(id string) {
+	p.mu.Lock()
+	subscription, ok := p.subscriptions[id]
+	if !ok || p.checking[id] {
+		p.mu.Unlock()
+		return
+	}
+	p.checking[id] = true
+	snapshot := *subscription
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.checking, id)
+		p.mu.Unlock()
+	}()
+
+	enqueued, errCheck := p.check(snapshot)
+	lastError := ""
+	if errCheck != nil {
+		lastError = errCheck.Error()
+		logrus.LogrusLoggerWithContext(p.ctx).Errorf("Subscription %s check failed: %s", id, lastError)
+	} else if enqueued > 0 {
+		emit.Message(p.ctx, emit.MessageStatusInfo, fmt.Sprintf("%s: %d new entries queued", snapshot.Name, enqueued))
+	}
+	p.mu.Lock()
+	if stored, ok := p.subscriptions[id]; ok {
+		stored.LastCheck = time.Now()
+		stored.LastError = lastError
+		stored.Seeded = stored.Seeded || errCheck == nil
+	}
+	p.mu.Unlock()
+	if err := p.save(); err != nil {
+		logrus.LogrusLoggerWithContext(p.ctx).Error(err.Error())
+	}
+}
+
+func (p *Poller) check(subscription Subscription) (int, error) {
+	ctx, cancel := context.WithTimeout(*p.ctx, 10*time.Minute)
+	defer cancel()
+	ytd := ytdlp.NewYtDlp(&ctx, subscription.Url, ytdlp.SplitState{}, emit.NewEmitDownload(&ctx))
+	entries, errExtract := ytd.FlatExtract()
+	if errExtract != nil {
+		return 0, errExtract
+	}
+	archive, errArchive := ytdlp.LoadArchive()
+	if errArchive != nil {
+		return 0, errArchive
+	}
+	fresh := make([]ytdlp.Entry, 0)
+	for _, entry := range entries {
+		if !archive[entry.ArchiveKey()] {
+			fresh = append(fresh, entry)
+		}
+	}
+	if !subscription.Seeded && !subscription.Backfill {
+		keys := make([]string, 0, len(fresh))
+		for _, entry := range fresh {
+			keys = append(keys, entry.ArchiveKey())
+		}
+		return 0, ytdlp.AppendArchive(keys)
+	}
+	enqueued := 0
+	rejected := make([]string, 0)
+	// playlists list the newest entry first, queue the oldest first
+	for i := len(fresh) - 1; i >= 0; i-- {
+		if !subscription.Filters.Match(fresh[i]) {
+			rejected = append(rejected, fresh[i].ArchiveKey())
+			continue
+		}
+		if p.enqueue(subscription, fresh[i]) {
+			enqueued++
+		}
+	}
+	// filtered entries are archived like downloaded ones so every poll does
+	// not judge them again
+	return enqueued, ytdlp.AppendArchive(rejected)
+}
+
+func (p *Poller) save() error {
+	data, err := json.MarshalIndent(p.List(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(p.storePath, data, 0644)
+}
+
+// Match applies the filters to what flat extraction returned, fields the
+// extractor left empty pass here and are checked by yt-dlp through
+// MatchFilter and --dateafter once the entry is resolved.
+func (f Filters) Match(entry ytdlp.Entry) bool {
+	if f.TitleRegex != "" {
+		re, err := regexp.Compile("(?i)" + f.TitleRegex)
+		if err != nil || !re.MatchString(entry.Title) {
+			return false
+		}
+	}
+	if entry.Duration > 0 {
+		if f.MinDuration > 0 && entry.Duration < f.MinDuration {
+			return false
+		}
+		if f.MaxDuration > 0 && entry.Duration > f.MaxDuration {
+			return false
+		}
+	}
+	if f.DateAfter != "" {
+		date := entry.UploadDate
+		if date == "" && entry.Timestamp > 0 {
+			date = time.Unix(entry.Timestamp, 0).UTC().Format("20060102")
+		}
+		if date != "" && date < f.DateAfter {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchFilter turns the duration and title filters into a yt-dlp
+// --match-filter. An entry without a duration fails a duration condition.
+func (f Filters) MatchFilter() string {
+	conditions := make([]string, 0, 3)
+	if f.MinDuration > 0 {
+		conditions = append(conditions, fmt.Sprintf("duration>=%s", strconv.FormatFloat(f.MinDuration, 'f', -1, 64)))
+	}
+	if f.MaxDuration > 0 {
+		conditions = append(conditions, fmt.Sprintf("duration<=%s", strconv.FormatFloat(f.MaxDuration, 'f', -1, 64)))
+	}
+	if f.TitleRegex != "" {
+		// yt-dlp splits conditions on a bare & and ends the value at a quote
+		quoted := strings.NewReplacer(`'`, `\'`, "&", `\&`).Replace("(?i)" + f.TitleRegex)
+		conditions = append(conditions, fmt.Sprintf("title~='%s'", quoted))
+	}
+	return strings.Join(conditions, " & ")
+}
+
+func validate(subscription *Subscription) error {
+	subscription.Url = strings.TrimSpace(subscription.Url)
+	if subscription.Url == "" {
+		return errors.New("subscription url is required")
+	}
+	if subscription.Name == "" {
+		subscription.Name = subscription.Url
+	}
+	if subscription.IntervalMinutes <= 0 {
+		subscription.IntervalMinutes = defaultIntervalMinutes
+	}
+	if _, err := ytdlp.GetPreset(subscription.Preset); err != nil {
+		return err
+	}
+	if subscription.Filters.TitleRegex != "" {
+		if _, err := regexp.Compile(subscription.Filters.TitleRegex); err != nil {
+			return fmt.Errorf("invalid title regex: %s", err.Error())
+		}
+	}
+	if subscription.Filters.DateAfter != "" {
+		if _, err := time.Parse("20060102", subscription.Filters.DateAfter); err != nil {
+			return fmt.Errorf("invalid date %q, expected YYYYMMDD", subscription.Filters.DateAfter)
+		}
+	}
+	if subscription.Filters.MaxDuration > 0 && subscription.Filters.MinDuration > subscription.Filters.MaxDuration {
+		return errors.New("minimum duration is longer than maximum duration")
+	}
+	return nil
+}
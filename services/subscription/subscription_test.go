@@ -0,0 +1,81 @@
+package subscription
+
+import (
+	"reflect"
+	"testing"
+	ytdlp "ytdlp/services/yt-dlp"
+)
+
+func TestFiltersMatchFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters Filters
+		want    string
+	}{
+		{name: "none", filters: Filters{DateAfter: "20240101"}, want: ""},
+		{name: "durations", filters: Filters{MinDuration: 60, MaxDuration: 1800.5}, want: "duration>=60 & duration<=1800.5"},
+		{name: "title", filters: Filters{TitleRegex: "live|vod"}, want: "title~='(?i)live|vod'"},
+		{name: "title with quote and ampersand", filters: Filters{TitleRegex: "rock & roll's"}, want: `title~='(?i)rock \& roll\'s'`},
+		{name: "all", filters: Filters{MinDuration: 10, TitleRegex: "x"}, want: "duration>=10 & title~='(?i)x'"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.filters.MatchFilter(); got != test.want {
+				t.Errorf("MatchFilter() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFiltersMatch(t *testing.T) {
+	filters := Filters{TitleRegex: "^episode", MinDuration: 60, MaxDuration: 600, DateAfter: "20240501"}
+	tests := []struct {
+		name  string
+		entry ytdlp.Entry
+		want  bool
+	}{
+		{name: "all fields pass", entry: ytdlp.Entry{Title: "Episode 1", Duration: 120, UploadDate: "20240502"}, want: true},
+		{name: "title is case insensitive", entry: ytdlp.Entry{Title: "EPISODE 2"}, want: true},
+		{name: "title mismatch", entry: ytdlp.Entry{Title: "Trailer", Duration: 120}, want: false},
+		{name: "too short", entry: ytdlp.Entry{Title: "Episode", Duration: 30}, want: false},
+		{name: "too long", entry: ytdlp.Entry{Title: "Episode", Duration: 601}, want: false},
+		{name: "missing duration is left to yt-dlp", entry: ytdlp.Entry{Title: "Episode"}, want: true},
+		{name: "too old", entry: ytdlp.Entry{Title: "Episode", UploadDate: "20240430"}, want: false},
+		{name: "date from the timestamp", entry: ytdlp.Entry{Title: "Episode", Timestamp: 1714435200}, want: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := filters.Match(test.entry); got != test.want {
+				t.Errorf("Match() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFlatEntryUrls(t *testing.T) {
+	output := `{"extractor_key":"YoutubeTab","entries":[
+		{"id":"a","url":"https://www.youtube.com/watch?v=a","ie_key":"Youtube"},
+		{"id":"b","url":"b","webpage_url":"https://www.youtube.com/watch?v=b","ie_key":"Youtube"},
+		{"id":"c","ie_key":"Youtube"},
+		{"id":"d","ie_key":"Unknown"},
+		{"id":"e"},
+		{"url":"https://www.youtube.com/watch?v=f"}
+	]}`
+	entries, err := ytdlp.ParseFlatPlaylist([]byte(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, entry := range entries {
+		got[entry.ID] = entry.Url
+	}
+	// d and e have no url of their own, the channel url must not stand in
+	want := map[string]string{
+		"a": "https://www.youtube.com/watch?v=a",
+		"b": "https://www.youtube.com/watch?v=b",
+		"c": "https://www.youtube.com/watch?v=c",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("entry urls = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,75 @@
+package ytdlp
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"ytdlp/utils"
+)
+
+var archiveMu sync.Mutex
+
+func GetArchivePath() string {
+	return filepath.Join(utils.GetHomeDir(), "archive.txt")
+}
+
+// LoadArchive reads the yt-dlp download archive, one "extractor id" per line.
+func LoadArchive() (map[string]bool, error) {
+	archiveMu.Lock()
+	defer archiveMu.Unlock()
+	archive := map[string]bool{}
+	file, errOpen := os.Open(GetArchivePath())
+	if os.IsNotExist(errOpen) {
+		return archive, nil
+	}
+	if errOpen != nil {
+		return nil, errOpen
+	}
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(file)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			archive[line] = true
+		}
+	}
+	return archive, scanner.Err()
+}
+
+// AppendArchive marks entries as downloaded without fetching them.
+func AppendArchive(keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	archiveMu.Lock()
+	defer archiveMu.Unlock()
+	if err := utils.CheckOrCreateDir(filepath.Dir(GetArchivePath())); err != nil {
+		return err
+	}
+	file, errOpen := os.OpenFile(GetArchivePath(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if errOpen != nil {
+		return errOpen
+	}
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(file)
+	_, err := file.WriteString(strings.Join(keys, "\n") + "\n")
+	return err
+}
+
+// EnsureArchived adds key unless yt-dlp already wrote it, entries yt-dlp
+// skipped for their date or a match filter are recorded this way so a
+// subscription does not queue them again.
+func EnsureArchived(key string) error {
+	archive, err := LoadArchive()
+	if err != nil {
+		return err
+	}
+	if archive[key] {
+		return nil
+	}
+	return AppendArchive([]string{key})
+}
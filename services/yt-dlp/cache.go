@@ -0,0 +1,48 @@
+package ytdlp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"ytdlp/helpers/logrus"
+	"ytdlp/utils"
+	"ytdlp/utils/resource"
+)
+
+// DownloadToCache fetches the whole video into the cache dir once and returns
+// the local file path, later calls reuse the cached file.
+func (y *YtDlp) DownloadToCache() (string, error) {
+	select {
+	case <-(*y.ctx).Done():
+		return "", fmt.Errorf("context canceled")
+	default:
+	}
+	if err := utils.CheckOrCreateDir(utils.GetCacheDir()); err != nil {
+		return "", err
+	}
+	cmd := exec.CommandContext(*y.ctx, resource.YtDlpPath(),
+		y.videoUrl,
+		"--no-playlist",
+		"--no-simulate",
+		"--print", "after_move:filepath",
+		"-S", "res:480,fps",
+		"--merge-output-format", "mp4",
+		"--output", filepath.Join(utils.GetCacheDir(), "%(extractor)s-%(id)s.%(ext)s"),
+		"--ffmpeg-location", resource.FFmpegPath(),
+	)
+	utils.HideWindow(cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		logrus.LogrusLoggerWithContext(y.ctx).Error(err.Error())
+		return "", err
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	filePath := strings.TrimSpace(lines[len(lines)-1])
+	if _, errStat := os.Stat(filePath); errStat != nil {
+		return "", errors.New("cached source not found after download")
+	}
+	return filePath, nil
+}
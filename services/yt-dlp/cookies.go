@@ -0,0 +1,73 @@
+package ytdlp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"ytdlp/utils"
+)
+
+// Cookie has the fields of a browser extension cookie, see chrome.cookies.
+type Cookie struct {
+	Domain         string  `json:"domain"`
+	HostOnly       bool    `json:"hostOnly"`
+	Path           string  `json:"path"`
+	Secure         bool    `json:"secure"`
+	HttpOnly       bool    `json:"httpOnly"`
+	Session        bool    `json:"session"`
+	ExpirationDate float64 `json:"expirationDate"`
+	Name           string  `json:"name"`
+	Value          string  `json:"value"`
+}
+
+func GetCookiesDir() string {
+	return filepath.Join(utils.GetTempDir(), "cookies")
+}
+
+// FormatCookies renders cookies in the Netscape format yt-dlp reads with
+// --cookies.
+func FormatCookies(cookies []Cookie) string {
+	var b strings.Builder
+	b.WriteString("# Netscape HTTP Cookie File\n")
+	boolString := func(value bool) string {
+		if value {
+			return "TRUE"
+		}
+		return "FALSE"
+	}
+	for _, cookie := range cookies {
+		domain := cookie.Domain
+		if !cookie.HostOnly && !strings.HasPrefix(domain, ".") {
+			domain = "." + domain
+		}
+		if cookie.HttpOnly {
+			domain = "#HttpOnly_" + domain
+		}
+		path := cookie.Path
+		if path == "" {
+			path = "/"
+		}
+		expires := int64(cookie.ExpirationDate)
+		if cookie.Session {
+			expires = 0
+		}
+		fmt.Fprintf(&b, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			domain, boolString(!cookie.HostOnly), path, boolString(cookie.Secure), expires, cookie.Name, cookie.Value)
+	}
+	return b.String()
+}
+
+// WriteCookiesFile stores a Netscape cookie file only the current user can
+// read and returns its path, remove it once the download is over.
+func WriteCookiesFile(content string) (string, error) {
+	dir := GetCookiesDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s.txt", utils.GenerateSessionID()))
+	if err := utils.WriteFileAtomic(path, []byte(content), 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
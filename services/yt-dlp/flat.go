@@ -0,0 +1,91 @@
+package ytdlp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strings"
+	"ytdlp/helpers/logrus"
+	"ytdlp/utils"
+	"ytdlp/utils/resource"
+)
+
+type Entry struct {
+	ID         string  `json:"id"`
+	Url        string  `json:"url"`
+	WebpageUrl string  `json:"webpage_url"`
+	Title      string  `json:"title"`
+	Duration   float64 `json:"duration"`
+	UploadDate string  `json:"upload_date"`
+	Timestamp  int64   `json:"timestamp"`
+	IeKey      string  `json:"ie_key"`
+}
+
+type flatPlaylist struct {
+	Extractor string  `json:"extractor_key"`
+	Entries   []Entry `json:"entries"`
+}
+
+// ArchiveKey matches the line yt-dlp writes to the download archive.
+func (e Entry) ArchiveKey() string {
+	return strings.ToLower(e.IeKey) + " " + e.ID
+}
+
+// FlatExtract lists the entries of a channel or playlist without resolving
+// each video, which keeps polling cheap.
+func (y *YtDlp) FlatExtract() ([]Entry, error) {
+	cmd := exec.CommandContext(*y.ctx, resource.YtDlpPath(),
+		y.videoUrl,
+		"--flat-playlist",
+		"--dump-single-json",
+		"--no-warnings",
+	)
+	utils.HideWindow(cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			logrus.LogrusLoggerWithContext(y.ctx).Error(string(exitErr.Stderr))
+		}
+		return nil, fmt.Errorf("flat extraction failed: %s", err.Error())
+	}
+	return ParseFlatPlaylist(output)
+}
+
+// entryUrls builds the url of an entry that only came with its id, for the
+// extractors whose video urls are known.
+var entryUrls = map[string]string{
+	"youtube":     "https://www.youtube.com/watch?v=%s",
+	"vimeo":       "https://vimeo.com/%s",
+	"dailymotion": "https://www.dailymotion.com/video/%s",
+}
+
+// ParseFlatPlaylist reads the output of a flat extraction. Entries without
+// an url of their own are skipped, falling back to the playlist url would
+// download all of it as one video.
+func ParseFlatPlaylist(data []byte) ([]Entry, error) {
+	var playlist flatPlaylist
+	if errJson := json.Unmarshal(data, &playlist); errJson != nil {
+		return nil, errJson
+	}
+	entries := make([]Entry, 0, len(playlist.Entries))
+	for _, entry := range playlist.Entries {
+		if entry.ID == "" {
+			continue
+		}
+		if entry.IeKey == "" {
+			entry.IeKey = playlist.Extractor
+		}
+		switch {
+		case strings.Contains(entry.Url, "://"):
+		case strings.Contains(entry.WebpageUrl, "://"):
+			entry.Url = entry.WebpageUrl
+		case entryUrls[strings.ToLower(entry.IeKey)] != "":
+			entry.Url = fmt.Sprintf(entryUrls[strings.ToLower(entry.IeKey)], url.QueryEscape(entry.ID))
+		default:
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -0,0 +1,72 @@
+package ytdlp
+
+import (
+	"fmt"
+	"sort"
+	"ytdlp/utils/settings"
+)
+
+const (
+	DefaultPreset = settings.DefaultPreset
+)
+
+type Preset struct {
+	Name      string   `json:"name"`
+	Title     string   `json:"title"`
+	Args      []string `json:"args"`
+	AudioOnly bool     `json:"audioOnly"`
+}
+
+var presets = map[string]Preset{
+	"480p": {
+		Name:  "480p",
+		Title: "Video 480p",
+		Args:  []string{"-S", "res:480,fps"},
+	},
+	"720p": {
+		Name:  "720p",
+		Title: "Video 720p",
+		Args:  []string{"-S", "res:720,fps"},
+	},
+	"1080p": {
+		Name:  "1080p",
+		Title: "Video 1080p",
+		Args:  []string{"-S", "res:1080,fps"},
+	},
+	"best": {
+		Name:  "best",
+		Title: "Best quality",
+		Args:  []string{},
+	},
+	"audio": {
+		Name:      "audio",
+		Title:     "Audio only (mp3)",
+		Args:      []string{"-f", "bestaudio/best", "-x", "--audio-format", "mp3"},
+		AudioOnly: true,
+	},
+}
+
+func GetPreset(name string) (Preset, error) {
+	if name == "" {
+		name = settings.Current().Preset
+		if _, ok := presets[name]; !ok {
+			name = DefaultPreset
+		}
+	}
+	preset, ok := presets[name]
+	if !ok {
+		return Preset{}, fmt.Errorf("unknown preset %q", name)
+	}
+	return preset, nil
+}
+
+func ListPresets() []Preset {
+	list := make([]Preset, 0, len(presets))
+	for _, preset := range presets {
+		list = append(list, preset)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
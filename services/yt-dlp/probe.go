@@ -0,0 +1,67 @@
+package ytdlp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"ytdlp/utils"
+	"ytdlp/utils/resource"
+)
+
+type ProbeInfo struct {
+	ID         string  `json:"id"`
+	Title      string  `json:"title"`
+	Duration   float64 `json:"duration"`
+	Extractor  string  `json:"extractor"`
+	Uploader   string  `json:"uploader"`
+	Thumbnail  string  `json:"thumbnail"`
+	WebpageUrl string  `json:"webpageUrl"`
+	IsLive     bool    `json:"isLive"`
+	Formats    int     `json:"formats"`
+}
+
+type probeJson struct {
+	ID         string            `json:"id"`
+	Title      string            `json:"title"`
+	Duration   float64           `json:"duration"`
+	Extractor  string            `json:"extractor_key"`
+	Uploader   string            `json:"uploader"`
+	Thumbnail  string            `json:"thumbnail"`
+	WebpageUrl string            `json:"webpage_url"`
+	IsLive     bool              `json:"is_live"`
+	Formats    []json.RawMessage `json:"formats"`
+}
+
+// Probe reads the metadata of a single video without downloading it.
+func (y *YtDlp) Probe() (ProbeInfo, error) {
+	cmd := exec.CommandContext(*y.ctx, resource.YtDlpPath(),
+		y.videoUrl,
+		"--dump-single-json",
+		"--no-playlist",
+		"--no-warnings",
+	)
+	utils.HideWindow(cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return ProbeInfo{}, fmt.Errorf("probe: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return ProbeInfo{}, fmt.Errorf("probe: %s", err.Error())
+	}
+	var raw probeJson
+	if errJson := json.Unmarshal(output, &raw); errJson != nil {
+		return ProbeInfo{}, errJson
+	}
+	return ProbeInfo{
+		ID:         raw.ID,
+		Title:      raw.Title,
+		Duration:   raw.Duration,
+		Extractor:  raw.Extractor,
+		Uploader:   raw.Uploader,
+		Thumbnail:  raw.Thumbnail,
+		WebpageUrl: raw.WebpageUrl,
+		IsLive:     raw.IsLive,
+		Formats:    len(raw.Formats),
+	}, nil
+}
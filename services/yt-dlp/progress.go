@@ -0,0 +1,145 @@
+package ytdlp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"ytdlp/helpers/logrus"
+	"ytdlp/utils"
+	"ytdlp/utils/emit"
+)
+
+const (
+	progressPrefix = "[ytdlp-progress] "
+	filePrefix     = "[ytdlp-file] "
+)
+
+// progressArgs make yt-dlp print machine readable progress and the final
+// path of every file, one per line.
+var progressArgs = []string{
+	"--newline",
+	"--progress",
+	"--no-simulate",
+	"--progress-template", "download:" + progressPrefix + "%(progress)j",
+	"--print", "after_move:" + filePrefix + "%(filepath)s",
+}
+
+type DownloadProgress struct {
+	Percent         float64 `json:"percent"`
+	DownloadedBytes int64   `json:"downloadedBytes"`
+	TotalBytes      int64   `json:"totalBytes"`
+	Speed           float64 `json:"speed"`
+	Eta             int64   `json:"eta"`
+}
+
+type ytDlpProgress struct {
+	Status             string   `json:"status"`
+	DownloadedBytes    int64    `json:"downloaded_bytes"`
+	TotalBytes         int64    `json:"total_bytes"`
+	TotalBytesEstimate float64  `json:"total_bytes_estimate"`
+	Speed              *float64 `json:"speed"`
+	Eta                *int64   `json:"eta"`
+}
+
+func (p DownloadProgress) String() string {
+	text := fmt.Sprintf("%5.1f%%", p.Percent)
+	if p.TotalBytes > 0 {
+		text += " of " + utils.ByteCountDecimal(p.TotalBytes)
+	}
+	if p.Speed > 0 {
+		text += fmt.Sprintf(" at %s/s", utils.ByteCountDecimal(int64(p.Speed)))
+	}
+	if p.Eta > 0 {
+		text += " ETA " + FormatTimestamp(float64(p.Eta))
+	}
+	return text
+}
+
+// run starts yt-dlp, turns its progress lines into download events and
+// returns the tail of stderr when it exits with an error.
+func (y *YtDlp) run(cmd *exec.Cmd) error {
+	stdout, errStdout := cmd.StdoutPipe()
+	if errStdout != nil {
+		return errStdout
+	}
+	stderr, errStderr := cmd.StderrPipe()
+	if errStderr != nil {
+		return errStderr
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	var mu sync.Mutex
+	var lastError string
+	var wg sync.WaitGroup
+	wg.Add(2)
+	read := func(reader io.Reader) {
+		defer wg.Done()
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, progressPrefix):
+				y.emitProgress(strings.TrimPrefix(line, progressPrefix))
+			case strings.HasPrefix(line, filePrefix):
+				mu.Lock()
+				y.files = append(y.files, strings.TrimPrefix(line, filePrefix))
+				mu.Unlock()
+			case strings.HasPrefix(line, "ERROR:"):
+				mu.Lock()
+				lastError = strings.TrimSpace(strings.TrimPrefix(line, "ERROR:"))
+				mu.Unlock()
+				logrus.LogrusLoggerWithContext(y.ctx).Error(line)
+			default:
+				logrus.LogrusLoggerWithContext(y.ctx).Debug(line)
+			}
+		}
+	}
+	go read(stdout)
+	go read(stderr)
+	wg.Wait()
+	if err := cmd.Wait(); err != nil {
+		if (*y.ctx).Err() != nil {
+			return fmt.Errorf("context canceled")
+		}
+		if lastError != "" {
+			return errors.New(lastError)
+		}
+		return fmt.Errorf("yt-dlp: %s", err.Error())
+	}
+	return nil
+}
+
+func (y *YtDlp) emitProgress(line string) {
+	var raw ytDlpProgress
+	if err := json.Unmarshal([]byte(line), &raw); err != nil {
+		return
+	}
+	progress := DownloadProgress{
+		DownloadedBytes: raw.DownloadedBytes,
+		TotalBytes:      raw.TotalBytes,
+	}
+	if progress.TotalBytes == 0 {
+		progress.TotalBytes = int64(raw.TotalBytesEstimate)
+	}
+	if progress.TotalBytes > 0 {
+		progress.Percent = float64(progress.DownloadedBytes) / float64(progress.TotalBytes) * 100
+	}
+	if raw.Speed != nil {
+		progress.Speed = *raw.Speed
+	}
+	if raw.Eta != nil {
+		progress.Eta = *raw.Eta
+	}
+	status := emit.DownloadStatusDownload
+	if raw.Status == "finished" {
+		status = emit.DownloadStatusProcessing
+		progress.Percent = 100
+	}
+	y.emitDownload.Progress(status, progress)
+}
@@ -0,0 +1,238 @@
+package ytdlp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"ytdlp/helpers/logrus"
+	"ytdlp/utils"
+	"ytdlp/utils/emit"
+	"ytdlp/utils/resource"
+	"ytdlp/utils/settings"
+)
+
+type RecordOptions struct {
+	WaitForStream  bool   `json:"waitForStream"`
+	FromStart      bool   `json:"fromStart"`
+	MaxDuration    int    `json:"maxDuration"`
+	StopAt         string `json:"stopAt"`
+	SegmentSeconds int    `json:"segmentSeconds"`
+}
+
+type RecordProgress struct {
+	OutputDir string  `json:"outputDir"`
+	Size      int64   `json:"size"`
+	SizeText  string  `json:"sizeText"`
+	Duration  float64 `json:"duration"`
+	Segments  int     `json:"segments"`
+}
+
+type Recorder struct {
+	ctx          *context.Context
+	emitDownload emit.EmitDownload
+	channelUrl   string
+	options      RecordOptions
+}
+
+func NewRecorder(ctx *context.Context, channelUrl string, options RecordOptions, emitDownload emit.EmitDownload) *Recorder {
+	return &Recorder{
+		ctx:          ctx,
+		channelUrl:   channelUrl,
+		options:      options,
+		emitDownload: emitDownload,
+	}
+}
+
+// Record keeps recording until the stream ends, a limit is reached or the
+// context is cancelled, a stop requested by the user is not an error.
+func (r *Recorder) Record() (string, error) {
+	ctx := *r.ctx
+	if r.options.StopAt != "" {
+		stopAt, errStopAt := ParseStopAt(r.options.StopAt, time.Now())
+		if errStopAt != nil {
+			return "", errStopAt
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, stopAt)
+		defer cancel()
+	}
+	outDir := filepath.Join(settings.Current().OutputDir, "live",
+		fmt.Sprintf("%s_%s", utils.SanitizeFilename(utils.ParseVideoId(r.channelUrl)), time.Now().Format("20060102_150405")))
+	if err := utils.CheckOrCreateDir(outDir); err != nil {
+		return "", err
+	}
+
+	ffmpegArgs := make([]string, 0)
+	if r.options.MaxDuration > 0 {
+		ffmpegArgs = append(ffmpegArgs, "-t", strconv.Itoa(r.options.MaxDuration))
+	}
+	if r.options.SegmentSeconds > 0 {
+		ffmpegArgs = append(ffmpegArgs,
+			"-f", "segment",
+			"-segment_time", strconv.Itoa(r.options.SegmentSeconds),
+			"-reset_timestamps", "1",
+			filepath.Join(outDir, "part_%04d.ts"),
+		)
+	} else {
+		ffmpegArgs = append(ffmpegArgs, "-f", "mpegts", filepath.Join(outDir, "record.ts"))
+	}
+
+	r.emitDownload.Start()
+	defer r.emitDownload.Stop()
+	done := make(chan struct{})
+	defer close(done)
+	go r.watchProgress(outDir, done)
+
+	err := PipeLive(&ctx, r.channelUrl, r.ytDlpArgs(), ffmpegArgs)
+	if ctx.Err() != nil {
+		logrus.LogrusLoggerWithContext(r.ctx).Infof("Recording of %s stopped: %s", r.channelUrl, ctx.Err().Error())
+		err = nil
+	}
+	r.emitDownload.Progress(emit.DownloadStatusDone, r.progress(outDir, time.Time{}))
+	return outDir, err
+}
+
+func (r *Recorder) ytDlpArgs() []string {
+	args := make([]string, 0)
+	if r.options.WaitForStream {
+		args = append(args, "--wait-for-video", "30")
+	}
+	if r.options.FromStart {
+		args = append(args, "--live-from-start")
+	}
+	return args
+}
+
+func (r *Recorder) watchProgress(outDir string, done chan struct{}) {
+	// duration counts from the first recorded byte, not while waiting for the stream
+	var startedAt time.Time
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			progress := r.progress(outDir, startedAt)
+			if startedAt.IsZero() && progress.Size > 0 {
+				startedAt = time.Now()
+			}
+			r.emitDownload.Progress(emit.DownloadStatusDownload, progress)
+		}
+	}
+}
+
+func (r *Recorder) progress(outDir string, startedAt time.Time) RecordProgress {
+	progress := RecordProgress{OutputDir: outDir}
+	files, _ := filepath.Glob(filepath.Join(outDir, "*.ts"))
+	for _, file := range files {
+		if size, err := utils.GetFileSize(file); err == nil {
+			progress.Size += size
+		}
+	}
+	progress.Segments = len(files)
+	progress.SizeText = utils.ByteCountDecimal(progress.Size)
+	if !startedAt.IsZero() {
+		progress.Duration = time.Since(startedAt).Seconds()
+	}
+	return progress
+}
+
+// pipeExitGrace is how long yt-dlp may take to exit after ffmpeg, past it
+// yt-dlp is still streaming and gets killed
+const pipeExitGrace = time.Second
+
+// PipeLive streams the live url from yt-dlp into ffmpeg over stdout, ffmpeg
+// remuxes without re-encoding into whatever output ffmpegArgs describe.
+func PipeLive(ctx *context.Context, liveUrl string, ytDlpArgs []string, ffmpegArgs []string) error {
+	dlArgs := append([]string{
+		liveUrl,
+		"--no-part",
+		"--no-playlist",
+		"--quiet",
+		"--ffmpeg-location", resource.FFmpegPath(),
+		"--output", "-",
+	}, ytDlpArgs...)
+	dlCmd := exec.CommandContext(*ctx, resource.YtDlpPath(), dlArgs...)
+	utils.HideWindow(dlCmd)
+	ffArgs := append([]string{"-hide_banner", "-loglevel", "error", "-i", "pipe:0", "-map", "0", "-c", "copy"}, ffmpegArgs...)
+	ffCmd := exec.CommandContext(*ctx, resource.FFmpegPath(), ffArgs...)
+	utils.HideWindow(ffCmd)
+
+	// ffmpeg reads the pipe of yt-dlp itself, nothing in this process relays
+	// the stream that could stall when one side stops
+	stdout, errPipe := dlCmd.StdoutPipe()
+	if errPipe != nil {
+		return errPipe
+	}
+	ffCmd.Stdin = stdout
+	var dlStderr, ffStderr strings.Builder
+	dlCmd.Stderr = &dlStderr
+	ffCmd.Stderr = &ffStderr
+
+	if err := ffCmd.Start(); err != nil {
+		_ = stdout.Close()
+		return err
+	}
+	if err := dlCmd.Start(); err != nil {
+		_ = ffCmd.Process.Kill()
+		_ = ffCmd.Wait()
+		return err
+	}
+	// the read end stays open here too, so yt-dlp never dies of a broken
+	// pipe on its own, once ffmpeg is done it is still running and killed
+	var errDl error
+	dlDone := make(chan struct{})
+	go func() {
+		defer close(dlDone)
+		errDl = dlCmd.Wait()
+	}()
+	errFf := ffCmd.Wait()
+	killedByUs := false
+	select {
+	case <-dlDone:
+		// yt-dlp ended first, e.g. the stream is over or it failed, the grace
+		// covers reaping it after ffmpeg read the end of its output
+	case <-time.After(pipeExitGrace):
+		killedByUs = true
+		_ = dlCmd.Process.Kill()
+		<-dlDone
+	}
+	if errFf != nil {
+		logrus.LogrusLoggerWithContext(ctx).Error(ffStderr.String())
+		return fmt.Errorf("ffmpeg: %s", errFf.Error())
+	}
+	if errDl != nil && !killedByUs {
+		logrus.LogrusLoggerWithContext(ctx).Error(dlStderr.String())
+		if message := strings.TrimSpace(dlStderr.String()); message != "" {
+			return errors.New(message)
+		}
+		return fmt.Errorf("yt-dlp: %s", errDl.Error())
+	}
+	if message := strings.TrimSpace(dlStderr.String()); message != "" && !killedByUs {
+		logrus.LogrusLoggerWithContext(ctx).Warnf("yt-dlp: %s", message)
+	}
+	return nil
+}
+
+// ParseStopAt accepts an RFC 3339 timestamp or a local "15:04" clock time,
+// a clock time already passed today refers to tomorrow.
+func ParseStopAt(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	clock, err := time.ParseInLocation("15:04", value, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid stop time %q", value)
+	}
+	stopAt := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+	if !stopAt.After(now) {
+		stopAt = stopAt.AddDate(0, 0, 1)
+	}
+	return stopAt, nil
+}
@@ -0,0 +1,174 @@
+package ytdlp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"ytdlp/helpers/logrus"
+	"ytdlp/utils"
+	"ytdlp/utils/emit"
+	"ytdlp/utils/resource"
+	"ytdlp/utils/settings"
+)
+
+const (
+	replaySegmentSeconds = 2
+	// replayListName is the list ffmpeg rewrites each time it closes a segment
+	replayListName = "segments.csv"
+)
+
+type ReplayOptions struct {
+	BufferMinutes int `json:"bufferMinutes"`
+}
+
+type ReplayBuffer struct {
+	ctx          *context.Context
+	emitDownload emit.EmitDownload
+	channelUrl   string
+	options      ReplayOptions
+	bufferDir    string
+	// mu keeps the segments in place while a save reads them, closed is set
+	// once Close removed them
+	mu     sync.RWMutex
+	closed bool
+}
+
+func NewReplayBuffer(ctx *context.Context, channelUrl string, options ReplayOptions, emitDownload emit.EmitDownload) *ReplayBuffer {
+	if options.BufferMinutes <= 0 {
+		options.BufferMinutes = 5
+	}
+	return &ReplayBuffer{
+		ctx:          ctx,
+		channelUrl:   channelUrl,
+		options:      options,
+		emitDownload: emitDownload,
+		bufferDir:    filepath.Join(utils.GetTempDir(), "replay", utils.GenerateSessionID()),
+	}
+}
+
+// Run keeps the last BufferMinutes of the stream on disk as a ring of short
+// segments that ffmpeg overwrites in place, until the context is cancelled.
+// The segments stay on disk until Close.
+func (r *ReplayBuffer) Run() error {
+	if err := utils.CheckOrCreateDir(r.bufferDir); err != nil {
+		return err
+	}
+	// two extra segments: the one being written and a spare, so the oldest
+	// listed segment is not overwritten while a save reads it
+	wrap := r.options.BufferMinutes*60/replaySegmentSeconds + 2
+	ffmpegArgs := []string{
+		"-f", "segment",
+		"-segment_time", strconv.Itoa(replaySegmentSeconds),
+		"-segment_wrap", strconv.Itoa(wrap),
+		"-segment_list", filepath.Join(r.bufferDir, replayListName),
+		"-segment_list_type", "csv",
+		"-segment_list_size", strconv.Itoa(wrap - 2),
+		"-segment_format", "mpegts",
+		"-reset_timestamps", "1",
+		filepath.Join(r.bufferDir, "buffer_%05d.ts"),
+	}
+	r.emitDownload.Start()
+	defer r.emitDownload.Stop()
+	err := PipeLive(r.ctx, r.channelUrl, []string{"--wait-for-video", "30"}, ffmpegArgs)
+	if (*r.ctx).Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// Close removes the segments once no save is reading them, later saves fail.
+func (r *ReplayBuffer) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	return utils.CheckOrDeleteDir(r.bufferDir)
+}
+
+// Save concatenates the segments covering the last seconds of the buffer into
+// a clip in the output dir and returns its path.
+func (r *ReplayBuffer) Save(lastSeconds int) (string, error) {
+	if lastSeconds <= 0 {
+		return "", errors.New("replay length must be positive")
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return "", errors.New("replay buffer has stopped")
+	}
+	segments, err := r.recentSegments(lastSeconds)
+	if err != nil {
+		return "", err
+	}
+	outDir := filepath.Join(settings.Current().OutputDir, "replay")
+	if errDir := utils.CheckOrCreateDir(outDir); errDir != nil {
+		return "", errDir
+	}
+	listPath := filepath.Join(r.bufferDir, fmt.Sprintf("concat_%s.txt", utils.GenerateSessionID()))
+	lines := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		lines = append(lines, fmt.Sprintf("file '%s'", strings.ReplaceAll(segment, "'", `'\''`)))
+	}
+	if errWrite := os.WriteFile(listPath, []byte(strings.Join(lines, "\n")), 0644); errWrite != nil {
+		return "", errWrite
+	}
+	defer func() {
+		_ = utils.CheckOrDeleteFile(listPath)
+	}()
+	outPath := filepath.Join(outDir, fmt.Sprintf("%s_%s.mp4",
+		utils.SanitizeFilename(utils.ParseVideoId(r.channelUrl)), time.Now().Format("20060102_150405")))
+	cmd := exec.CommandContext(*r.ctx, resource.FFmpegPath(),
+		"-hide_banner", "-loglevel", "error",
+		"-f", "concat", "-safe", "0",
+		"-i", listPath,
+		"-c", "copy",
+		"-bsf:a", "aac_adtstoasc",
+		"-movflags", "+faststart",
+		"-y", outPath,
+	)
+	utils.HideWindow(cmd)
+	if output, errCmd := cmd.CombinedOutput(); errCmd != nil {
+		logrus.LogrusLoggerWithContext(r.ctx).Error(string(output))
+		return "", fmt.Errorf("save replay: %s", errCmd.Error())
+	}
+	return outPath, nil
+}
+
+// recentSegments returns the finished segments covering lastSeconds, oldest
+// first. ffmpeg cuts on keyframes, so the durations it lists are summed
+// instead of counting segments, and the segment being written is not listed
+// until it is closed.
+func (r *ReplayBuffer) recentSegments(lastSeconds int) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(r.bufferDir, replayListName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	lines := strings.Split(string(data), "\n")
+	// the last line is empty or still being written
+	lines = lines[:len(lines)-1]
+	paths := make([]string, 0)
+	covered := 0.0
+	for i := len(lines) - 1; i >= 0 && covered < float64(lastSeconds); i-- {
+		fields := strings.Split(strings.TrimSpace(lines[i]), ",")
+		if len(fields) != 3 {
+			continue
+		}
+		start, errStart := strconv.ParseFloat(fields[1], 64)
+		end, errEnd := strconv.ParseFloat(fields[2], 64)
+		if errStart != nil || errEnd != nil || end <= start {
+			continue
+		}
+		paths = append([]string{filepath.Join(r.bufferDir, filepath.Base(fields[0]))}, paths...)
+		covered += end - start
+	}
+	if len(paths) == 0 {
+		return nil, errors.New("replay buffer has no finished segment yet")
+	}
+	return paths, nil
+}
@@ -0,0 +1,53 @@
+package ytdlp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRecentSegments(t *testing.T) {
+	list := "buffer_00003.ts,10.000000,14.200000\n" +
+		"buffer_00004.ts,14.200000,15.100000\n" +
+		"buffer_00005.ts,15.100000,21.000000\n"
+	tests := []struct {
+		name        string
+		list        string
+		lastSeconds int
+		want        []string
+		wantErr     bool
+	}{
+		{name: "one long segment covers it", list: list, lastSeconds: 5, want: []string{"buffer_00005.ts"}},
+		{name: "durations are summed", list: list, lastSeconds: 6, want: []string{"buffer_00004.ts", "buffer_00005.ts"}},
+		{name: "more than the buffer holds", list: list, lastSeconds: 60, want: []string{"buffer_00003.ts", "buffer_00004.ts", "buffer_00005.ts"}},
+		{name: "half written line is left out", list: list + "buffer_00006.ts,21.0", lastSeconds: 1, want: []string{"buffer_00005.ts"}},
+		{name: "nothing finished", list: "", lastSeconds: 5, wantErr: true},
+		{name: "only a half written line", list: "buffer_00000.ts,0.000000,2.0", lastSeconds: 5, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &ReplayBuffer{bufferDir: t.TempDir()}
+			if err := os.WriteFile(filepath.Join(r.bufferDir, replayListName), []byte(test.list), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := r.recentSegments(test.lastSeconds)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("recentSegments() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+			want := make([]string, 0, len(test.want))
+			for _, name := range test.want {
+				want = append(want, filepath.Join(r.bufferDir, name))
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("recentSegments() = %q, want %q", got, want)
+			}
+		})
+	}
+	if _, err := (&ReplayBuffer{bufferDir: t.TempDir()}).recentSegments(5); err == nil {
+		t.Error("recentSegments() without a list = nil, want an error")
+	}
+}
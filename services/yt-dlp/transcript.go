@@ -0,0 +1,334 @@
+package ytdlp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"ytdlp/helpers/logrus"
+	"ytdlp/utils"
+	"ytdlp/utils/resource"
+)
+
+const (
+	// TranscriptLangs is asked for when no language is requested, .*-orig
+	// are the automatic captions in the original language of the video
+	TranscriptLangs = "en.*,vi.*,.*-orig,-live_chat"
+)
+
+// rollingTransition is the longest cue auto captions use to scroll a line
+const rollingTransition = 0.05
+
+var (
+	vttTagRegexp    = regexp.MustCompile(`<[^>]*>`)
+	vttTimingRegexp = regexp.MustCompile(`^((?:\d+:)?\d{2}:\d{2}\.\d{3})\s+-->\s+((?:\d+:)?\d{2}:\d{2}\.\d{3})`)
+)
+
+type TranscriptCue struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+type TranscriptMatch struct {
+	Start     float64 `json:"start"`
+	End       float64 `json:"end"`
+	StartTime string  `json:"startTime"`
+	EndTime   string  `json:"endTime"`
+	Text      string  `json:"text"`
+}
+
+type ClipPadding struct {
+	Before float64 `json:"before"`
+	After  float64 `json:"after"`
+}
+
+type Transcript struct {
+	Url string `json:"url"`
+	// Lang is the subtitle language the cues were read from
+	Lang string          `json:"lang"`
+	Cues []TranscriptCue `json:"cues"`
+	// lower-cased cue text, same index as Cues
+	index []string
+}
+
+func NewTranscript(url string, lang string, cues []TranscriptCue) *Transcript {
+	index := make([]string, len(cues))
+	for i, cue := range cues {
+		index[i] = normalizeText(cue.Text)
+	}
+	return &Transcript{
+		Url:   url,
+		Lang:  lang,
+		Cues:  cues,
+		index: index,
+	}
+}
+
+// Search returns every cue containing the query. A phrase split across two
+// consecutive cues is matched too, spanning both of them.
+func (t *Transcript) Search(query string) []TranscriptMatch {
+	query = normalizeText(query)
+	matches := make([]TranscriptMatch, 0)
+	if query == "" {
+		return matches
+	}
+	for i, text := range t.index {
+		last := i
+		if !strings.Contains(text, query) {
+			if i+1 >= len(t.index) || strings.Contains(t.index[i+1], query) {
+				continue
+			}
+			if !strings.Contains(text+" "+t.index[i+1], query) {
+				continue
+			}
+			last = i + 1
+		}
+		texts := make([]string, 0, last-i+1)
+		for j := i; j <= last; j++ {
+			texts = append(texts, t.Cues[j].Text)
+		}
+		matches = append(matches, TranscriptMatch{
+			Start:     t.Cues[i].Start,
+			End:       t.Cues[last].End,
+			StartTime: FormatTimestamp(t.Cues[i].Start),
+			EndTime:   FormatTimestamp(t.Cues[last].End),
+			Text:      strings.Join(texts, " "),
+		})
+	}
+	return matches
+}
+
+func (m TranscriptMatch) ToSplit(padding ClipPadding) SplitState {
+	start := math.Max(0, m.Start-math.Max(0, padding.Before))
+	end := m.End + math.Max(0, padding.After)
+	return SplitState{
+		Start: FormatTimestamp(math.Floor(start)),
+		End:   FormatTimestamp(math.Ceil(end)),
+	}
+}
+
+func FormatTimestamp(seconds float64) string {
+	total := int(math.Max(0, seconds))
+	return fmt.Sprintf("%02d:%02d:%02d", total/3600, total%3600/60, total%60)
+}
+
+// DownloadTranscript reads the subtitles of the video in lang, or in the
+// original language of the video when lang is empty.
+func (y *YtDlp) DownloadTranscript(lang string) (*Transcript, error) {
+	select {
+	case <-(*y.ctx).Done():
+		return nil, fmt.Errorf("context canceled")
+	default:
+	}
+	outDir := filepath.Join(utils.GetTempDir(), "transcript", utils.GenerateSessionID())
+	if err := utils.CheckOrCreateDir(outDir); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errRemove := utils.CheckOrDeleteDir(outDir); errRemove != nil {
+			logrus.LogrusLoggerWithContext(y.ctx).Error(errRemove.Error())
+		}
+	}()
+	langs := TranscriptLangs
+	if lang != "" {
+		langs = lang + ".*,-live_chat"
+	}
+	cmd := exec.CommandContext(*y.ctx, resource.YtDlpPath(),
+		y.videoUrl,
+		"--skip-download",
+		// print the language without skipping the subtitle files
+		"--no-simulate",
+		"--print", "%(language)s",
+		"--write-subs",
+		"--write-auto-subs",
+		"--sub-langs", langs,
+		"--sub-format", "vtt",
+		"--convert-subs", "vtt",
+		"--no-playlist",
+		"--ffmpeg-location", resource.FFmpegPath(),
+		"--output", filepath.Join(outDir, "transcript.%(ext)s"),
+	)
+	utils.HideWindow(cmd)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		logrus.LogrusLoggerWithContext(y.ctx).Error(stderr.String())
+		return nil, err
+	}
+	files, errGlob := filepath.Glob(filepath.Join(outDir, "*.vtt"))
+	if errGlob != nil {
+		return nil, errGlob
+	}
+	if len(files) == 0 {
+		return nil, errors.New("no subtitles or captions available for this video")
+	}
+	original := strings.TrimSpace(string(output))
+	picked, errPick := PickSubtitle(files, lang, original)
+	if errPick != nil {
+		return nil, errPick
+	}
+	cues, err := ParseVtt(picked)
+	if err != nil {
+		return nil, err
+	}
+	return NewTranscript(y.videoUrl, subtitleLang(picked), cues), nil
+}
+
+// PickSubtitle chooses the file of the requested language, without one the
+// original language is preferred, its automatic captions first since they
+// follow the audio.
+func PickSubtitle(files []string, lang string, original string) (string, error) {
+	byLang := map[string]string{}
+	langs := make([]string, 0, len(files))
+	for _, file := range files {
+		byLang[subtitleLang(file)] = file
+		langs = append(langs, subtitleLang(file))
+	}
+	sort.Strings(langs)
+	find := func(candidates ...string) string {
+		for _, candidate := range candidates {
+			if file, ok := byLang[candidate]; ok {
+				return file
+			}
+		}
+		for _, candidate := range candidates {
+			for _, l := range langs {
+				if strings.HasPrefix(l, candidate+"-") {
+					return byLang[l]
+				}
+			}
+		}
+		return ""
+	}
+	if lang != "" {
+		if file := find(lang); file != "" {
+			return file, nil
+		}
+		return "", fmt.Errorf("no %s subtitles for this video, available: %s", lang, strings.Join(langs, ", "))
+	}
+	if original != "" && original != "NA" {
+		if file := find(original+"-orig", original); file != "" {
+			return file, nil
+		}
+	}
+	for _, l := range langs {
+		if strings.HasSuffix(l, "-orig") {
+			return byLang[l], nil
+		}
+	}
+	return byLang[langs[0]], nil
+}
+
+// subtitleLang reads the language from a name like transcript.en-orig.vtt.
+func subtitleLang(file string) string {
+	name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+func ParseVtt(filePath string) ([]TranscriptCue, error) {
+	file, errOpen := os.Open(filePath)
+	if errOpen != nil {
+		return nil, errOpen
+	}
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(file)
+
+	cues := make([]TranscriptCue, 0)
+	var current *TranscriptCue
+	var lines, previous []string
+	flush := func() {
+		if current == nil {
+			return
+		}
+		texts := make([]string, 0, len(lines))
+		for _, line := range lines {
+			if line != "" {
+				texts = append(texts, line)
+			}
+		}
+		shown := texts
+		// rolling auto captions start each cue with the lines the previous
+		// one ended with and switch lines in cues of a few milliseconds,
+		// other single line cues are real speech even if repeated
+		if len(texts) > 1 || len(previous) > 1 || current.End-current.Start < rollingTransition {
+			texts = texts[rollingOverlap(previous, texts):]
+		}
+		previous = shown
+		if len(texts) > 0 {
+			current.Text = strings.Join(texts, " ")
+			cues = append(cues, *current)
+		}
+		current = nil
+		lines = nil
+	}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		raw := strings.TrimRight(scanner.Text(), "\r")
+		line := strings.TrimSpace(raw)
+		if timing := vttTimingRegexp.FindStringSubmatch(line); timing != nil {
+			flush()
+			current = &TranscriptCue{
+				Start: parseVttTime(timing[1]),
+				End:   parseVttTime(timing[2]),
+			}
+			continue
+		}
+		// only an empty line ends a cue, auto captions hold lines of a space
+		if raw == "" {
+			flush()
+			continue
+		}
+		if current != nil {
+			lines = append(lines, strings.TrimSpace(vttTagRegexp.ReplaceAllString(line, "")))
+		}
+	}
+	flush()
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return cues, nil
+}
+
+// rollingOverlap is the number of leading lines of current that repeat the
+// last lines of previous.
+func rollingOverlap(previous []string, current []string) int {
+	for n := min(len(previous), len(current)); n > 0; n-- {
+		if slices.Equal(previous[len(previous)-n:], current[:n]) {
+			return n
+		}
+	}
+	return 0
+}
+
+func parseVttTime(value string) float64 {
+	parts := strings.Split(value, ":")
+	seconds := 0.0
+	for _, part := range parts {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0
+		}
+		seconds = seconds*60 + v
+	}
+	return seconds
+}
+
+func normalizeText(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
@@ -0,0 +1,129 @@
+package ytdlp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseVtt(t *testing.T) {
+	tests := []struct {
+		name string
+		vtt  string
+		want []TranscriptCue
+	}{
+		{
+			name: "manual subtitles with tags and hours",
+			vtt: `WEBVTT
+Kind: captions
+
+1
+00:00:01.000 --> 00:00:02.500
+<c>Hello</c> there
+
+01:00:03.000 --> 01:00:04.000 align:start position:0%
+General Kenobi
+`,
+			want: []TranscriptCue{
+				{Start: 1, End: 2.5, Text: "Hello there"},
+				{Start: 3603, End: 3604, Text: "General Kenobi"},
+			},
+		},
+		{
+			name: "repeated single line cues are kept",
+			vtt: `WEBVTT
+
+00:00:01.000 --> 00:00:02.000
+Yeah.
+
+00:00:02.000 --> 00:00:03.000
+Yeah.
+`,
+			want: []TranscriptCue{
+				{Start: 1, End: 2, Text: "Yeah."},
+				{Start: 2, End: 3, Text: "Yeah."},
+			},
+		},
+		{
+			name: "rolling auto captions keep new text only",
+			vtt: "WEBVTT\n\n" +
+				"00:00:00.000 --> 00:00:02.000 align:start position:0%\n \nfirst<00:00:01.000><c> line</c>\n\n" +
+				"00:00:02.000 --> 00:00:02.010 align:start position:0%\nfirst line\n \n\n" +
+				"00:00:02.010 --> 00:00:04.000 align:start position:0%\nfirst line\nsecond<00:00:03.000><c> line</c>\n\n" +
+				"00:00:04.000 --> 00:00:04.010 align:start position:0%\nsecond line\n \n\n" +
+				"00:00:04.010 --> 00:00:06.000 align:start position:0%\nsecond line\nthird line\n",
+			want: []TranscriptCue{
+				{Start: 0, End: 2, Text: "first line"},
+				{Start: 2.01, End: 4, Text: "second line"},
+				{Start: 4.01, End: 6, Text: "third line"},
+			},
+		},
+		{
+			name: "rolling cue repeating all lines adds nothing",
+			vtt: `WEBVTT
+
+00:00:00.000 --> 00:00:02.000
+one
+two
+
+00:00:02.000 --> 00:00:04.000
+one
+two
+`,
+			want: []TranscriptCue{
+				{Start: 0, End: 2, Text: "one two"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "transcript.en.vtt")
+			if err := os.WriteFile(filePath, []byte(test.vtt), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ParseVtt(filePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("ParseVtt() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPickSubtitle(t *testing.T) {
+	files := []string{
+		"/tmp/x/transcript.en.vtt",
+		"/tmp/x/transcript.en-US.vtt",
+		"/tmp/x/transcript.ja-orig.vtt",
+		"/tmp/x/transcript.vi.vtt",
+	}
+	tests := []struct {
+		name     string
+		lang     string
+		original string
+		want     string
+		wantErr  bool
+	}{
+		{name: "requested exact", lang: "vi", original: "ja", want: "/tmp/x/transcript.vi.vtt"},
+		{name: "requested region", lang: "en", want: "/tmp/x/transcript.en.vtt"},
+		{name: "requested prefix", lang: "ja", want: "/tmp/x/transcript.ja-orig.vtt"},
+		{name: "requested missing", lang: "de", wantErr: true},
+		{name: "original automatic captions", original: "ja", want: "/tmp/x/transcript.ja-orig.vtt"},
+		{name: "original by prefix", original: "en", want: "/tmp/x/transcript.en.vtt"},
+		{name: "unknown original prefers -orig", original: "NA", want: "/tmp/x/transcript.ja-orig.vtt"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := PickSubtitle(files, test.lang, test.original)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("PickSubtitle() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("PickSubtitle() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
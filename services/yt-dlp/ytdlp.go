@@ -4,10 +4,11 @@ import (
 	"context"
 	"fmt"
 	"os/exec"
-	"syscall"
-	"time"
+	"path/filepath"
 	"ytdlp/utils"
 	"ytdlp/utils/emit"
+	"ytdlp/utils/resource"
+	"ytdlp/utils/settings"
 )
 
 type SplitState struct {
@@ -15,11 +16,28 @@ type SplitState struct {
 	End   string `json:"end"`
 }
 
+type DownloadOptions struct {
+	Preset string `json:"preset"`
+	// UseArchive records finished entries in the download archive and skips
+	// the ones already there, clips leave it off so a video can be cut twice
+	UseArchive bool   `json:"useArchive"`
+	DateAfter  string `json:"dateAfter"`
+	// MatchFilter is a yt-dlp --match-filter, conditions joined by &
+	MatchFilter    string `json:"matchFilter,omitempty"`
+	OutputTemplate string `json:"outputTemplate"`
+	// WriteInfoJson keeps the metadata and artwork next to the media file
+	WriteInfoJson bool `json:"writeInfoJson"`
+	// CookiesFile is a Netscape cookie file for sites that need a login
+	CookiesFile string `json:"cookiesFile,omitempty"`
+}
+
 type YtDlp struct {
 	ctx          *context.Context
 	emitDownload emit.EmitDownload
 	videoUrl     string
 	split        SplitState
+	options      DownloadOptions
+	files        []string
 }
 
 func NewYtDlp(ctx *context.Context, videoUrl string, split SplitState, emitDownload emit.EmitDownload) *YtDlp {
@@ -31,28 +49,63 @@ func NewYtDlp(ctx *context.Context, videoUrl string, split SplitState, emitDownl
 	}
 }
 
+func (y *YtDlp) SetOptions(options DownloadOptions) {
+	y.options = options
+}
+
 func (y *YtDlp) Download() error {
 	select {
 	case <-(*y.ctx).Done():
 		return fmt.Errorf("context canceled")
 	default:
 	}
-	ytDlpPath := utils.GetYtDlpPath()
-	ffmpegPath := utils.GetFFmpegPath()
-	cmd := exec.Command(ytDlpPath,
-		"--download-sections", fmt.Sprintf("*%v-%v", y.split.Start, y.split.End),
-		"--force-keyframes-at-cuts",
-		y.videoUrl,
-		"--force-overwrites",
-		"-S", "res:480,fps",
-		"--output", "%UserProfile%\\Downloads\\ytdlp\\%(extractor)s\\%(id)s.%(ext)s",
+	ytDlpPath := resource.YtDlpPath()
+	ffmpegPath := resource.FFmpegPath()
+	preset, errPreset := GetPreset(y.options.Preset)
+	if errPreset != nil {
+		return errPreset
+	}
+	outputTemplate := y.options.OutputTemplate
+	if outputTemplate == "" {
+		outputTemplate = filepath.Join(settings.Current().OutputDir, "%(extractor)s", "%(id)s.%(ext)s")
+	}
+	args := make([]string, 0)
+	// without a range the whole video, or every entry of a playlist, is fetched
+	if y.split.Start != "" || y.split.End != "" {
+		args = append(args,
+			"--download-sections", fmt.Sprintf("*%v-%v", y.split.Start, y.split.End),
+			"--force-keyframes-at-cuts",
+		)
+	}
+	if y.options.UseArchive {
+		args = append(args, "--download-archive", GetArchivePath())
+	} else {
+		args = append(args, "--force-overwrites")
+	}
+	if y.options.DateAfter != "" {
+		args = append(args, "--dateafter", y.options.DateAfter)
+	}
+	if y.options.MatchFilter != "" {
+		args = append(args, "--match-filter", y.options.MatchFilter)
+	}
+	if y.options.WriteInfoJson {
+		args = append(args, "--write-info-json", "--write-thumbnail", "--convert-thumbnails", "jpg")
+	}
+	if y.options.CookiesFile != "" {
+		args = append(args, "--cookies", y.options.CookiesFile)
+	}
+	args = append(args, y.videoUrl)
+	args = append(args, preset.Args...)
+	args = append(args,
+		"--output", outputTemplate,
 		"--ffmpeg-location", ffmpegPath,
 	)
-	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
-	if err := cmd.Start(); err != nil {
-		return err
-	}
-	_ = cmd.Wait()
-	time.Sleep(1 * time.Second)
-	return nil
+	args = append(args, progressArgs...)
+	cmd := exec.CommandContext(*y.ctx, ytDlpPath, args...)
+	utils.HideWindow(cmd)
+	return y.run(cmd)
+}
+
+func (y *YtDlp) Files() []string {
+	return y.files
 }
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"ytdlp/helpers/logrus"
+	ytdlp "ytdlp/services/yt-dlp"
+	"ytdlp/utils/emit"
+	"ytdlp/utils/settings"
+)
+
+func (a *App) GetSettings() settings.Settings {
+	return settings.Current()
+}
+
+func (a *App) GetDefaultSettings() settings.Settings {
+	return settings.Defaults()
+}
+
+// UpdateSettings validates and saves the settings, applies them to the
+// running app and tells the frontend.
+func (a *App) UpdateSettings(next settings.Settings) (settings.Settings, error) {
+	if _, err := ytdlp.GetPreset(next.Preset); err != nil {
+		return settings.Settings{}, err
+	}
+	previous := settings.Current()
+	saved, err := settings.Default().Update(next)
+	if err != nil {
+		return settings.Settings{}, err
+	}
+	a.applySettings(previous, saved)
+	emit.Emit(&a.ctx, emit.SettingsChanged, saved)
+	return saved, nil
+}
+
+func (a *App) startSettings() {
+	current, err := settings.Default().Load()
+	if err != nil {
+		logrus.LogrusLoggerWithContext(&a.ctx).Warnf("Using default settings: %s", err.Error())
+	}
+	a.applySettings(settings.Settings{}, current)
+}
+
+// applySettings pushes the changes that running services do not read on
+// their own, the rest is looked up on every use.
+func (a *App) applySettings(previous settings.Settings, current settings.Settings) {
+	if err := logrus.SetLevel(current.LogLevel); err != nil {
+		logrus.LogrusLoggerWithContext(&a.ctx).Warn(err.Error())
+	}
+	if previous.ControlApi == current.ControlApi {
+		return
+	}
+	if errStop := a.controlApi.Stop(); errStop != nil {
+		logrus.LogrusLoggerWithContext(&a.ctx).Warn(errStop.Error())
+	}
+	if !current.ControlApi.Enabled {
+		return
+	}
+	if _, errStart := a.controlApi.Start(current.ControlApi.Port); errStart != nil {
+		logrus.LogrusLoggerWithContext(&a.ctx).Errorf("Start control api: %s", errStart.Error())
+		emit.Message(&a.ctx, emit.MessageStatusError, errStart.Error())
+	}
+}
@@ -1,21 +1,135 @@
 package main
 
 import (
+	"fmt"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"strings"
 	"ytdlp/helpers/logrus"
+	"ytdlp/utils"
 	"ytdlp/utils/emit"
+	"ytdlp/utils/resource"
 	"ytdlp/utils/setup"
 )
 
-func (a *App) SetupResources() {
-	st := setup.NewSetup(&a.ctx)
-	if errInstall := st.Install(); errInstall != nil {
-		buttons := []string{"Yes", "No"}
-		dialogOpts := runtime.MessageDialogOptions{Title: "Install resource Error", Message: errInstall.Error(), Type: runtime.WarningDialog, Buttons: buttons, DefaultButton: "Ok"}
+// SetupResources installs what is missing and returns one result per
+// resource, the failed ones can be installed again with RetryResource.
+func (a *App) SetupResources() setup.Summary {
+	summary, errInstall := setup.NewSetup(&a.ctx).Install()
+	a.finishSetup(summary, errInstall)
+	return summary
+}
+
+// RetryResource installs a single resource again, e.g. the one that failed.
+// It is refused while the same resource is still being installed.
+func (a *App) RetryResource(key string) (setup.Summary, error) {
+	summary, err := setup.NewSetup(&a.ctx).InstallResource(key)
+	a.finishSetup(summary, err)
+	return summary, err
+}
+
+// finishSetup tells the window about failures, or that it can start once
+// every resource resolves.
+func (a *App) finishSetup(summary setup.Summary, errInstall error) {
+	if errInstall != nil {
+		lines := make([]string, 0)
+		for _, result := range summary.Failed() {
+			lines = append(lines, fmt.Sprintf("%s: %s", result.Title, result.Error))
+		}
+		if len(lines) == 0 {
+			lines = append(lines, errInstall.Error())
+		}
+		dialogOpts := runtime.MessageDialogOptions{Title: "Install resource Error", Message: strings.Join(lines, "\n"), Type: runtime.WarningDialog}
 		if _, err := runtime.MessageDialog(a.ctx, dialogOpts); err != nil {
 			logrus.LogrusLoggerWithContext(&a.ctx).Error(err.Error())
 		}
 		return
 	}
-	runtime.EventsEmit(a.ctx, emit.ResourceFinish)
+	if !summary.Ready {
+		return
+	}
+	resolutions := resource.ResolveAll()
+	for _, resolution := range resolutions {
+		logrus.LogrusLoggerWithContext(&a.ctx).Infof("%s: %s (%s)", resolution.Title, resolution.Path, resolution.Source)
+	}
+	emit.Emit(&a.ctx, emit.ResourceFinish, resolutions)
+}
+
+// GetResourcePaths reports which ffmpeg and yt-dlp the app runs.
+func (a *App) GetResourcePaths() []resource.Resolution {
+	return resource.ResolveAll()
+}
+
+// CheckResourceUpdates compares the installed resources with the release
+// channel set in the settings, failed checks carry their error.
+func (a *App) CheckResourceUpdates() []setup.UpdateInfo {
+	return setup.CheckUpdates(&a.ctx)
+}
+
+// checkIdle refuses to replace a binary that running jobs may be using.
+func (a *App) checkIdle() error {
+	if jobs := a.jobs.List(); len(jobs) > 0 {
+		return fmt.Errorf("%d jobs are running, wait for them to finish or cancel them first", len(jobs))
+	}
+	return nil
+}
+
+func (a *App) UpdateResource(key string) (setup.UpdateInfo, error) {
+	if err := a.checkIdle(); err != nil {
+		return setup.UpdateInfo{}, err
+	}
+	info, err := setup.Update(&a.ctx, key)
+	if err != nil {
+		return info, err
+	}
+	emit.Message(&a.ctx, emit.MessageStatusSuccess, fmt.Sprintf("%s updated to %s", info.Title, info.Current))
+	return info, nil
+}
+
+func (a *App) RollbackResource(key string) (setup.UpdateInfo, error) {
+	if err := a.checkIdle(); err != nil {
+		return setup.UpdateInfo{}, err
+	}
+	return setup.Rollback(&a.ctx, key)
+}
+
+// InstallResourcesFromBundle installs ffmpeg and yt-dlp from a bundle zip
+// without network access, an empty path asks for the file.
+func (a *App) InstallResourcesFromBundle(path string) (setup.BundleManifest, error) {
+	if path == "" {
+		selected, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+			Title:   "Resource bundle",
+			Filters: []runtime.FileFilter{{DisplayName: "Resource bundle (*.zip)", Pattern: "*.zip"}},
+		})
+		if err != nil || selected == "" {
+			return setup.BundleManifest{}, err
+		}
+		path = selected
+	}
+	if err := a.checkIdle(); err != nil {
+		return setup.BundleManifest{}, err
+	}
+	manifest, err := setup.InstallBundle(&a.ctx, path)
+	if err != nil {
+		return manifest, err
+	}
+	emit.Emit(&a.ctx, emit.ResourceFinish, resource.ResolveAll())
+	emit.Message(&a.ctx, emit.MessageStatusSuccess, fmt.Sprintf("Installed %s from the bundle", manifest.Summary()))
+	return manifest, nil
+}
+
+// ExportResourceBundle saves the installed resources as a bundle zip for
+// machines without network access, an empty path asks where to save it.
+func (a *App) ExportResourceBundle(path string) (setup.BundleManifest, error) {
+	if path == "" {
+		selected, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+			Title:           "Save resource bundle",
+			DefaultFilename: fmt.Sprintf("ytdlp-resources-%s.zip", utils.GetPlatform()),
+			Filters:         []runtime.FileFilter{{DisplayName: "Resource bundle (*.zip)", Pattern: "*.zip"}},
+		})
+		if err != nil || selected == "" {
+			return setup.BundleManifest{}, err
+		}
+		path = selected
+	}
+	return setup.ExportBundle(&a.ctx, path)
 }
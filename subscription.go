@@ -0,0 +1,71 @@
+package main
+
+import (
+	"ytdlp/helpers/logrus"
+	"ytdlp/services/job"
+	"ytdlp/services/podcast"
+	"ytdlp/services/subscription"
+	ytdlp "ytdlp/services/yt-dlp"
+)
+
+func (a *App) AddSubscription(sub subscription.Subscription) (subscription.Subscription, error) {
+	return a.subscriptions.Add(sub)
+}
+
+func (a *App) ListSubscriptions() []subscription.Subscription {
+	return a.subscriptions.List()
+}
+
+func (a *App) PauseSubscription(id string) error {
+	return a.subscriptions.SetPaused(id, true)
+}
+
+func (a *App) ResumeSubscription(id string) error {
+	return a.subscriptions.SetPaused(id, false)
+}
+
+func (a *App) DeleteSubscription(id string) error {
+	return a.subscriptions.Delete(id)
+}
+
+func (a *App) CheckSubscription(id string) {
+	go a.subscriptions.Check(id)
+}
+
+func (a *App) ListPresets() []ytdlp.Preset {
+	return ytdlp.ListPresets()
+}
+
+func (a *App) startSubscriptions() {
+	a.subscriptions = subscription.NewPoller(&a.ctx, a.enqueueEntry)
+	if err := a.subscriptions.Load(); err != nil {
+		logrus.LogrusLoggerWithContext(&a.ctx).Error(err.Error())
+	}
+	a.subscriptions.Start()
+}
+
+func (a *App) enqueueEntry(sub subscription.Subscription, entry ytdlp.Entry) bool {
+	key := entry.ArchiveKey()
+	if a.queue.Pending(key) {
+		return false
+	}
+	options := ytdlp.DownloadOptions{
+		Preset:      sub.Preset,
+		UseArchive:  true,
+		DateAfter:   sub.Filters.DateAfter,
+		MatchFilter: sub.Filters.MatchFilter(),
+	}
+	// audio subscriptions are collected per feed for the podcast server
+	if preset, err := ytdlp.GetPreset(sub.Preset); err == nil && preset.AudioOnly {
+		options.OutputTemplate = podcast.OutputTemplate(sub.ID)
+		options.WriteInfoJson = true
+	}
+	a.queue.Enqueue(job.Task{
+		Url:     entry.Url,
+		Title:   entry.Title,
+		Source:  sub.ID,
+		Key:     key,
+		Options: options,
+	})
+	return true
+}
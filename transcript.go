@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"time"
+	ytdlp "ytdlp/services/yt-dlp"
+	"ytdlp/utils/emit"
+)
+
+const (
+	// transcripts are kept for a few searches, captions of a live or new
+	// video may still change
+	transcriptCacheSize = 20
+	transcriptCacheTtl  = time.Hour
+)
+
+type cachedTranscript struct {
+	transcript *ytdlp.Transcript
+	fetchedAt  time.Time
+}
+
+// transcriptCall is a fetch in flight, later callers for the same video wait
+// for it instead of starting yt-dlp again.
+type transcriptCall struct {
+	done       chan struct{}
+	transcript *ytdlp.Transcript
+	err        error
+}
+
+// SearchTranscript searches the subtitles of url in lang, an empty lang picks
+// the original language of the video.
+func (a *App) SearchTranscript(url string, query string, lang string) ([]ytdlp.TranscriptMatch, error) {
+	transcript, err := a.getTranscript(url, lang)
+	if err != nil {
+		emit.Message(&a.ctx, emit.MessageStatusError, err.Error())
+		return nil, err
+	}
+	return transcript.Search(query), nil
+}
+
+func (a *App) StartTranscriptClip(url string, match ytdlp.TranscriptMatch, padding ytdlp.ClipPadding) error {
+	return a.StartDownload(url, match.ToSplit(padding))
+}
+
+func (a *App) getTranscript(url string, lang string) (*ytdlp.Transcript, error) {
+	key := url + "\n" + lang
+	a.transcriptsMu.Lock()
+	if cached, ok := a.transcripts[key]; ok && time.Since(cached.fetchedAt) < transcriptCacheTtl {
+		a.transcriptsMu.Unlock()
+		return cached.transcript, nil
+	}
+	if call, ok := a.transcriptCalls[key]; ok {
+		a.transcriptsMu.Unlock()
+		<-call.done
+		return call.transcript, call.err
+	}
+	call := &transcriptCall{done: make(chan struct{})}
+	a.transcriptCalls[key] = call
+	a.transcriptsMu.Unlock()
+
+	// the lock is not held here, a slow video does not block other searches
+	ctx, cancel := context.WithCancel(a.ctx)
+	defer cancel()
+	ytd := ytdlp.NewYtDlp(&ctx, url, ytdlp.SplitState{}, emit.NewEmitDownload(&ctx))
+	call.transcript, call.err = ytd.DownloadTranscript(lang)
+
+	a.transcriptsMu.Lock()
+	delete(a.transcriptCalls, key)
+	if call.err == nil {
+		a.cacheTranscript(key, call.transcript)
+	}
+	a.transcriptsMu.Unlock()
+	close(call.done)
+	return call.transcript, call.err
+}
+
+// cacheTranscript stores a transcript and drops the oldest entries past the
+// cache size, transcriptsMu must be held.
+func (a *App) cacheTranscript(key string, transcript *ytdlp.Transcript) {
+	a.transcripts[key] = cachedTranscript{transcript: transcript, fetchedAt: time.Now()}
+	for len(a.transcripts) > transcriptCacheSize {
+		oldest := ""
+		for k, cached := range a.transcripts {
+			if oldest == "" || cached.fetchedAt.Before(a.transcripts[oldest].fetchedAt) {
+				oldest = k
+			}
+		}
+		delete(a.transcripts, oldest)
+	}
+}
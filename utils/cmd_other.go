@@ -0,0 +1,9 @@
+//go:build !windows
+
+package utils
+
+import (
+	"os/exec"
+)
+
+func HideWindow(cmd *exec.Cmd) {}
@@ -0,0 +1,10 @@
+package utils
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+func HideWindow(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+}
@@ -0,0 +1,195 @@
+package diagnose
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+	"ytdlp/utils"
+	"ytdlp/utils/resource"
+	"ytdlp/utils/settings"
+)
+
+type Status string
+
+const (
+	StatusOk      Status = "ok"
+	StatusWarning Status = "warning"
+	StatusError   Status = "error"
+)
+
+const (
+	// under these the home dir cannot hold a resource update or a download
+	lowSpace      = 1 << 30
+	criticalSpace = 200 << 20
+)
+
+type Check struct {
+	Name    string `json:"name"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+}
+
+type ResourceReport struct {
+	Key    string          `json:"key"`
+	Title  string          `json:"title"`
+	Path   string          `json:"path"`
+	Source resource.Source `json:"source"`
+	// Version is what the binary printed, empty when it did not run
+	Version    string `json:"version"`
+	Executable bool   `json:"executable"`
+	Status     Status `json:"status"`
+	Message    string `json:"message"`
+}
+
+type Report struct {
+	CreatedAt time.Time `json:"createdAt"`
+	Platform  string    `json:"platform"`
+	HomeDir   string    `json:"homeDir"`
+	// HomeMode tells whether the home dir is the default, portable or set
+	// by the flag or the environment
+	HomeMode  utils.HomeMode   `json:"homeMode"`
+	OutputDir string           `json:"outputDir"`
+	Resources []ResourceReport `json:"resources"`
+	Checks    []Check          `json:"checks"`
+	// Status is the worst status of all entries
+	Status Status `json:"status"`
+}
+
+// Run checks that every resource resolves to a binary that starts, that the
+// home dir has room and that downloads can be written.
+func Run() Report {
+	report := Report{
+		CreatedAt: time.Now(),
+		Platform:  utils.GetPlatform(),
+		OutputDir: settings.Current().OutputDir,
+		Resources: make([]ResourceReport, 0),
+		Checks:    make([]Check, 0),
+		Status:    StatusOk,
+	}
+	report.HomeDir, report.HomeMode = utils.ResolveHome()
+	for _, r := range resource.List() {
+		entry := checkResource(r)
+		report.Resources = append(report.Resources, entry)
+		report.Status = worst(report.Status, entry.Status)
+	}
+	for _, check := range []Check{checkWritable("Home dir", report.HomeDir), checkSpace("Home dir space", report.HomeDir), checkSpace("Output dir space", report.OutputDir), checkWritable("Output dir", report.OutputDir)} {
+		report.Checks = append(report.Checks, check)
+		report.Status = worst(report.Status, check.Status)
+	}
+	return report
+}
+
+func checkResource(r resource.Resource) ResourceReport {
+	resolution := r.Resolve()
+	entry := ResourceReport{
+		Key:    r.Key,
+		Title:  r.Title,
+		Path:   resolution.Path,
+		Source: resolution.Source,
+		Status: StatusOk,
+	}
+	if resolution.Source == resource.SourceMissing {
+		entry.Status = StatusError
+		entry.Message = fmt.Sprintf("%s is not installed, run the setup", r.Title)
+		if len(resolution.Rejected) > 0 {
+			entry.Message = fmt.Sprintf("no working %s (%s), run the setup or fix the resource settings", r.Title, resolution.Reasons())
+		}
+		return entry
+	}
+	info, errStat := os.Stat(resolution.Path)
+	if errStat != nil {
+		entry.Status = StatusError
+		entry.Message = errStat.Error()
+		return entry
+	}
+	// windows runs any .exe, elsewhere an archive may lose the mode bits
+	entry.Executable = runtime.GOOS == "windows" || info.Mode()&0111 != 0
+	if !entry.Executable {
+		entry.Status = StatusError
+		entry.Message = fmt.Sprintf("%s is not executable", resolution.Path)
+		return entry
+	}
+	version, errVersion := r.Version(resolution.Path)
+	if errVersion != nil {
+		entry.Status = StatusError
+		entry.Message = fmt.Sprintf("%s does not run, it may be corrupt or half extracted: %s", r.Title, errVersion.Error())
+		return entry
+	}
+	entry.Version = version
+	for _, rejection := range resolution.Rejected {
+		// a source that has a binary but was passed over is worth knowing about
+		if rejection.Path != "" {
+			entry.Status = StatusWarning
+			entry.Message = fmt.Sprintf("using the %s copy, %s", resolution.Source, rejection.String())
+			return entry
+		}
+	}
+	if resolution.Source == resource.SourceManaged && resolution.Version != "" && resolution.Version != version {
+		entry.Status = StatusWarning
+		entry.Message = fmt.Sprintf("installed as %s but reports %s", resolution.Version, version)
+	}
+	return entry
+}
+
+func checkSpace(name string, dir string) Check {
+	check := Check{Name: name, Status: StatusOk}
+	free, err := utils.FreeSpace(existingParent(dir))
+	if err != nil {
+		check.Status = StatusWarning
+		check.Message = fmt.Sprintf("free space of %s is unknown: %s", dir, err.Error())
+		return check
+	}
+	check.Message = fmt.Sprintf("%s free on the disk of %s", utils.ByteCountDecimal(int64(free)), dir)
+	switch {
+	case free < criticalSpace:
+		check.Status = StatusError
+	case free < lowSpace:
+		check.Status = StatusWarning
+	}
+	return check
+}
+
+// checkWritable creates and removes a file in dir, or in its nearest
+// existing parent when dir is not there yet, without creating dir itself.
+func checkWritable(name string, dir string) Check {
+	check := Check{Name: name, Status: StatusOk}
+	parent := existingParent(dir)
+	probe, err := os.CreateTemp(parent, ".write-check-*")
+	if err != nil {
+		check.Status = StatusError
+		check.Message = fmt.Sprintf("%s is not writable: %s", parent, err.Error())
+		return check
+	}
+	_ = probe.Close()
+	_ = os.Remove(probe.Name())
+	check.Message = fmt.Sprintf("%s is writable", dir)
+	if parent != dir {
+		check.Message = fmt.Sprintf("%s will be created, %s is writable", dir, parent)
+	}
+	return check
+}
+
+// existingParent walks up to a dir that exists, a fresh install has no home
+// dir yet but its disk can still be asked.
+func existingParent(dir string) string {
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+func worst(a Status, b Status) Status {
+	rank := map[Status]int{StatusOk: 0, StatusWarning: 1, StatusError: 2}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
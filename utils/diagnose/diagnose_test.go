@@ -0,0 +1,40 @@
+package diagnose
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestCheckWritable(t *testing.T) {
+	base := t.TempDir()
+	missing := filepath.Join(base, "a", "b")
+
+	check := checkWritable("Output dir", base)
+	if check.Status != StatusOk || check.Message != base+" is writable" {
+		t.Errorf("checkWritable(existing) = %+v", check)
+	}
+	check = checkWritable("Output dir", missing)
+	if check.Status != StatusOk || !strings.Contains(check.Message, "will be created") {
+		t.Errorf("checkWritable(missing) = %+v, want it to be created later", check)
+	}
+	if _, err := os.Stat(filepath.Join(base, "a")); !os.IsNotExist(err) {
+		t.Errorf("checkWritable created %s: %v", missing, err)
+	}
+	if entries, _ := os.ReadDir(base); len(entries) != 0 {
+		t.Errorf("probe files left behind: %v", entries)
+	}
+
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("permissions do not stop windows or root")
+	}
+	readOnly := filepath.Join(base, "ro")
+	if err := os.Mkdir(readOnly, 0555); err != nil {
+		t.Fatal(err)
+	}
+	if check := checkWritable("Output dir", filepath.Join(readOnly, "out")); check.Status != StatusError {
+		t.Errorf("checkWritable(read only parent) = %+v, want an error", check)
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+
+package utils
+
+import (
+	"syscall"
+)
+
+// FreeSpace returns the bytes available to the user on the disk holding path.
+func FreeSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
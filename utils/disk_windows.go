@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// FreeSpace returns the bytes available to the user on the disk holding path.
+func FreeSpace(path string) (uint64, error) {
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var available, total, free uint64
+	getDiskFreeSpaceEx := syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+	ok, _, errCall := getDiskFreeSpaceEx.Call(
+		uintptr(unsafe.Pointer(pathPtr)),
+		uintptr(unsafe.Pointer(&available)),
+		uintptr(unsafe.Pointer(&total)),
+		uintptr(unsafe.Pointer(&free)),
+	)
+	if ok == 0 {
+		return 0, errCall
+	}
+	return available, nil
+}
@@ -2,7 +2,6 @@ package emit
 
 import (
 	"context"
-	"github.com/wailsapp/wails/v2/pkg/runtime"
 	"ytdlp/helpers/logrus"
 )
 
@@ -23,10 +22,12 @@ const (
 )
 
 type EmitDownload struct {
-	ctx *context.Context
+	ctx   *context.Context
+	jobID string
 }
 
 type JsonDownloadStruct struct {
+	JobID    string         `json:"jobId,omitempty"`
 	Status   DownloadStatus `json:"status,omitempty"`
 	Message  string         `json:"message,omitempty"`
 	Progress interface{}    `json:"progress,omitempty"`
@@ -38,20 +39,36 @@ func NewEmitDownload(ctx *context.Context) EmitDownload {
 	}
 }
 
+func NewEmitJobDownload(ctx *context.Context, jobID string) EmitDownload {
+	return EmitDownload{
+		ctx:   ctx,
+		jobID: jobID,
+	}
+}
+
 func (e *EmitDownload) Start() {
 	emitKey := DownloadStart
-	runtime.EventsEmit(*e.ctx, emitKey, JsonDownloadStruct{})
+	Emit(e.ctx, emitKey, JsonDownloadStruct{
+		JobID: e.jobID,
+	})
 }
 
 func (e *EmitDownload) Stop() {
 	emitKey := DownloadStop
-	runtime.EventsEmit(*e.ctx, emitKey)
+	if e.jobID == "" {
+		Emit(e.ctx, emitKey)
+		return
+	}
+	Emit(e.ctx, emitKey, JsonDownloadStruct{
+		JobID: e.jobID,
+	})
 }
 
 func (e *EmitDownload) Progress(status DownloadStatus, progress interface{}) {
 	emitKey := DownloadProgress
 	logrus.LogrusLoggerWithContext(e.ctx).Debugf("Emitting progress: %s", emitKey)
-	runtime.EventsEmit(*e.ctx, emitKey, JsonDownloadStruct{
+	Emit(e.ctx, emitKey, JsonDownloadStruct{
+		JobID:    e.jobID,
 		Status:   status,
 		Progress: progress,
 	})
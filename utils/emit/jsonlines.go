@@ -0,0 +1,65 @@
+package emit
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type JsonLineStruct struct {
+	Time  time.Time   `json:"time"`
+	Event string      `json:"event"`
+	Data  interface{} `json:"data,omitempty"`
+}
+
+// JsonLinesSink writes one JSON object per event and line.
+type JsonLinesSink struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+func NewJsonLinesSink(w io.Writer) *JsonLinesSink {
+	return &JsonLinesSink{
+		w: w,
+	}
+}
+
+// NewJsonLinesFileSink appends the events to filePath, call Close when done.
+func NewJsonLinesFileSink(filePath string) (*JsonLinesSink, error) {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &JsonLinesSink{
+		w:      file,
+		closer: file,
+	}, nil
+}
+
+func (s *JsonLinesSink) Emit(event string, data ...interface{}) {
+	line := JsonLineStruct{Time: time.Now(), Event: event}
+	if len(data) > 0 {
+		line.Data = data[0]
+	}
+	encoded, err := json.Marshal(line)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, _ = s.w.Write(append(encoded, '\n'))
+}
+
+func (s *JsonLinesSink) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
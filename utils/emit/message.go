@@ -2,7 +2,10 @@ package emit
 
 import (
 	"context"
-	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	MessageEvent = "message"
 )
 
 type MessageStatus string
@@ -19,8 +22,8 @@ type JsonMessageStruct struct {
 }
 
 func Message(ctx *context.Context, status MessageStatus, message string) {
-	emitKey := "message"
-	runtime.EventsEmit(*ctx, emitKey, JsonMessageStruct{
+	emitKey := MessageEvent
+	Emit(ctx, emitKey, JsonMessageStruct{
 		Status:  status,
 		Message: message,
 	})
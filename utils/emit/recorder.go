@@ -0,0 +1,56 @@
+package emit
+
+import (
+	"sync"
+	"time"
+)
+
+type RecordedEvent struct {
+	Time  time.Time     `json:"time"`
+	Event string        `json:"event"`
+	Data  []interface{} `json:"data"`
+}
+
+// Recorder keeps every event in memory, for tests and embedding callers
+// that inspect events after the fact.
+type Recorder struct {
+	mu     sync.Mutex
+	events []RecordedEvent
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{
+		events: make([]RecordedEvent, 0),
+	}
+}
+
+func (r *Recorder) Emit(event string, data ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, RecordedEvent{Time: time.Now(), Event: event, Data: data})
+}
+
+func (r *Recorder) Events() []RecordedEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := make([]RecordedEvent, len(r.events))
+	copy(events, r.events)
+	return events
+}
+
+// Filter returns the recorded events with the given name.
+func (r *Recorder) Filter(event string) []RecordedEvent {
+	events := make([]RecordedEvent, 0)
+	for _, recorded := range r.Events() {
+		if recorded.Event == event {
+			events = append(events, recorded)
+		}
+	}
+	return events
+}
+
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = make([]RecordedEvent, 0)
+}
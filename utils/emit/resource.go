@@ -2,7 +2,6 @@ package emit
 
 import (
 	"context"
-	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 const (
@@ -11,6 +10,7 @@ const (
 	ResourceStop     = "resource-stop"
 	ResourceError    = "resource-error"
 	ResourceFinish   = "resource-finish"
+	ResourceSummary  = "resource-summary"
 )
 
 type EmitResource struct {
@@ -32,11 +32,16 @@ type JsonResourceStruct struct {
 	Title       string  `json:"title"`
 	Description string  `json:"description"`
 	Progress    float64 `json:"progress"`
+	// byte counts of a transfer, Total is -1 when the length is unknown
+	Downloaded int64   `json:"downloaded,omitempty"`
+	Total      int64   `json:"total,omitempty"`
+	Speed      float64 `json:"speed,omitempty"`
+	Eta        float64 `json:"eta,omitempty"`
 }
 
 func (e *EmitResource) Start() {
 	emitKey := ResourceStart
-	runtime.EventsEmit(*e.ctx, emitKey, JsonResourceStruct{
+	Emit(e.ctx, emitKey, JsonResourceStruct{
 		Key:         e.key,
 		Title:       e.title,
 		Description: "",
@@ -46,7 +51,7 @@ func (e *EmitResource) Start() {
 
 func (e *EmitResource) Progress(description string, progress float64) {
 	emitKey := ResourceProgress
-	runtime.EventsEmit(*e.ctx, emitKey, JsonResourceStruct{
+	Emit(e.ctx, emitKey, JsonResourceStruct{
 		Key:         e.key,
 		Title:       e.title,
 		Description: description,
@@ -54,9 +59,25 @@ func (e *EmitResource) Progress(description string, progress float64) {
 	})
 }
 
+// Transfer reports a download by bytes, speed in bytes per second and eta in
+// seconds.
+func (e *EmitResource) Transfer(description string, progress float64, downloaded int64, total int64, speed float64, eta float64) {
+	emitKey := ResourceProgress
+	Emit(e.ctx, emitKey, JsonResourceStruct{
+		Key:         e.key,
+		Title:       e.title,
+		Description: description,
+		Progress:    progress,
+		Downloaded:  downloaded,
+		Total:       total,
+		Speed:       speed,
+		Eta:         eta,
+	})
+}
+
 func (e *EmitResource) Stop() {
 	emitKey := ResourceStop
-	runtime.EventsEmit(*e.ctx, emitKey, JsonResourceStruct{
+	Emit(e.ctx, emitKey, JsonResourceStruct{
 		Key:         e.key,
 		Title:       e.title,
 		Description: "",
@@ -66,7 +87,7 @@ func (e *EmitResource) Stop() {
 
 func (e *EmitResource) Error(message string) {
 	emitKey := ResourceError
-	runtime.EventsEmit(*e.ctx, emitKey, JsonResourceStruct{
+	Emit(e.ctx, emitKey, JsonResourceStruct{
 		Key:         e.key,
 		Title:       e.title,
 		Description: message,
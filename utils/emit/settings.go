@@ -0,0 +1,5 @@
+package emit
+
+const (
+	SettingsChanged = "settings-changed"
+)
@@ -0,0 +1,71 @@
+package emit
+
+import (
+	"context"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Sink receives every event the emitters produce. The window, the terminal,
+// files and tests each plug in their own implementation.
+type Sink interface {
+	Emit(event string, data ...interface{})
+}
+
+type sinkKey struct{}
+
+// WithSink returns a context whose emitters write to sink, it keeps every
+// other value of ctx so the Wails runtime stays reachable.
+func WithSink(ctx context.Context, sink Sink) context.Context {
+	return context.WithValue(ctx, sinkKey{}, sink)
+}
+
+// SinkFromContext returns the sink set with WithSink, or the Wails window
+// when none was set.
+func SinkFromContext(ctx context.Context) Sink {
+	if sink, ok := ctx.Value(sinkKey{}).(Sink); ok {
+		return sink
+	}
+	return NewWailsSink(ctx)
+}
+
+// Emit sends a raw event through the sink of ctx.
+func Emit(ctx *context.Context, event string, data ...interface{}) {
+	SinkFromContext(*ctx).Emit(event, data...)
+}
+
+type WailsSink struct {
+	ctx context.Context
+}
+
+func NewWailsSink(ctx context.Context) *WailsSink {
+	return &WailsSink{
+		ctx: ctx,
+	}
+}
+
+func (s *WailsSink) Emit(event string, data ...interface{}) {
+	// runtime.EventsEmit exits the process on a context without the runtime,
+	// which is the case for the cli and library use
+	if s.ctx == nil || s.ctx.Value("events") == nil {
+		return
+	}
+	runtime.EventsEmit(s.ctx, event, data...)
+}
+
+type MultiSink struct {
+	sinks []Sink
+}
+
+// NewMultiSink fans every event out to all sinks, in order.
+func NewMultiSink(sinks ...Sink) *MultiSink {
+	return &MultiSink{
+		sinks: sinks,
+	}
+}
+
+func (s *MultiSink) Emit(event string, data ...interface{}) {
+	for _, sink := range s.sinks {
+		sink.Emit(event, data...)
+	}
+}
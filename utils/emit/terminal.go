@@ -0,0 +1,85 @@
+package emit
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+	"ytdlp/utils"
+)
+
+// TerminalSink prints events as short readable lines.
+type TerminalSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewTerminalSink(w io.Writer) *TerminalSink {
+	return &TerminalSink{
+		w: w,
+	}
+}
+
+func (s *TerminalSink) Emit(event string, data ...interface{}) {
+	var payload interface{}
+	if len(data) > 0 {
+		payload = data[0]
+	}
+	text := formatEvent(event, payload)
+	if text == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, _ = fmt.Fprintln(s.w, text)
+}
+
+func formatEvent(event string, payload interface{}) string {
+	switch data := payload.(type) {
+	case JsonDownloadStruct:
+		if event != DownloadProgress {
+			return fmt.Sprintf("[%s]", event)
+		}
+		if progress, ok := data.Progress.(fmt.Stringer); ok {
+			return fmt.Sprintf("[%s] %s", data.Status, progress.String())
+		}
+		if data.Progress != nil {
+			return fmt.Sprintf("[%s] %v", data.Status, data.Progress)
+		}
+		return fmt.Sprintf("[%s]", data.Status)
+	case JsonResourceStruct:
+		switch event {
+		case ResourceProgress:
+			if data.Downloaded > 0 {
+				return formatTransfer(data)
+			}
+			return fmt.Sprintf("[%s] %s %.1f%%", data.Key, data.Description, data.Progress)
+		case ResourceError:
+			return fmt.Sprintf("[%s] error: %s", data.Key, data.Description)
+		default:
+			return fmt.Sprintf("[%s] %s", data.Key, event)
+		}
+	case JsonMessageStruct:
+		return fmt.Sprintf("[%s] %s", data.Status, data.Message)
+	case nil:
+		return fmt.Sprintf("[%s]", event)
+	default:
+		return fmt.Sprintf("[%s] %v", event, data)
+	}
+}
+
+func formatTransfer(data JsonResourceStruct) string {
+	text := fmt.Sprintf("[%s] %s", data.Key, data.Description)
+	if data.Total > 0 {
+		text = fmt.Sprintf("%s %.1f%% %s/%s", text, data.Progress, utils.ByteCountDecimal(data.Downloaded), utils.ByteCountDecimal(data.Total))
+	} else {
+		text = fmt.Sprintf("%s %s", text, utils.ByteCountDecimal(data.Downloaded))
+	}
+	if data.Speed > 0 {
+		text = fmt.Sprintf("%s %s/s", text, utils.ByteCountDecimal(int64(data.Speed)))
+	}
+	if data.Eta > 0 {
+		text = fmt.Sprintf("%s eta %s", text, (time.Duration(data.Eta) * time.Second).String())
+	}
+	return text
+}
@@ -0,0 +1,455 @@
+package fetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"ytdlp/helpers/logrus"
+	"ytdlp/utils"
+)
+
+const (
+	PartialSuffix = ".partial"
+	metaSuffix    = ".partial.json"
+
+	maxRedirects = 10
+)
+
+var (
+	// ErrSlow ends a download that stayed under Options.MinSpeed
+	ErrSlow = errors.New("transfer too slow")
+	// ErrChecksum means the finished file is not the one that was expected
+	ErrChecksum = errors.New("checksum mismatch")
+	errRestart  = errors.New("the server ignored the resume request, starting over")
+)
+
+// Options tune a download, the zero value of a field keeps its default.
+type Options struct {
+	// Retries after a failed attempt, each one resumes where the last stopped
+	Retries int
+	// IdleTimeout aborts an attempt that receives no bytes for this long
+	IdleTimeout time.Duration
+	// Segments is the number of parallel range requests, 1 keeps a single
+	// stream. Files under two MinSegmentSize are never split.
+	Segments       int
+	MinSegmentSize int64
+	// MinSpeed in bytes per second over SlowWindow, a download that stays
+	// below it fails with ErrSlow. 0 waits for any speed.
+	MinSpeed   float64
+	SlowWindow time.Duration
+	// Sha256 is the expected digest of the file, empty skips the check
+	Sha256     string
+	OnProgress func(Progress)
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Retries:        5,
+		IdleTimeout:    time.Minute,
+		Segments:       4,
+		MinSegmentSize: 8 << 20,
+		SlowWindow:     30 * time.Second,
+	}
+}
+
+// Downloader fetches a url into a file. Bytes go to a .partial file next to
+// it first, so a failed or cancelled download continues from there.
+type Downloader struct {
+	ctx      *context.Context
+	url      string
+	filePath string
+	options  Options
+	client   *http.Client
+}
+
+// partialMeta remembers what the .partial file belongs to, Size and
+// Segments are only set for a segmented download.
+type partialMeta struct {
+	Url          string    `json:"url"`
+	ETag         string    `json:"etag"`
+	LastModified string    `json:"lastModified"`
+	Size         int64     `json:"size,omitempty"`
+	Segments     []segment `json:"segments,omitempty"`
+}
+
+type statusError struct {
+	code   int
+	status string
+}
+
+func (e statusError) Error() string {
+	return e.status
+}
+
+// retryable tells whether asking again can help, client errors other than
+// timeouts and rate limits do not change.
+func (e statusError) retryable() bool {
+	return e.code >= 500 || e.code == http.StatusRequestTimeout || e.code == http.StatusTooManyRequests
+}
+
+func NewDownloader(ctx *context.Context, url string, filePath string, options Options) *Downloader {
+	defaults := DefaultOptions()
+	if options.Retries == 0 {
+		options.Retries = defaults.Retries
+	}
+	if options.IdleTimeout == 0 {
+		options.IdleTimeout = defaults.IdleTimeout
+	}
+	if options.Segments == 0 {
+		options.Segments = 1
+	}
+	if options.MinSegmentSize == 0 {
+		options.MinSegmentSize = defaults.MinSegmentSize
+	}
+	if options.SlowWindow == 0 {
+		options.SlowWindow = defaults.SlowWindow
+	}
+	return &Downloader{
+		ctx:      ctx,
+		url:      url,
+		filePath: filePath,
+		options:  options,
+		client:   NewClient(),
+	}
+}
+
+// NewClient returns a client with connection timeouts, the body is guarded
+// by the idle timeout of the download instead of a total deadline.
+func NewClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: 15 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = 15 * time.Second
+	transport.ResponseHeaderTimeout = 30 * time.Second
+	return &http.Client{
+		Transport:     transport,
+		CheckRedirect: checkRedirect,
+	}
+}
+
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if via[0].URL.Scheme == "https" && req.URL.Scheme != "https" {
+		return fmt.Errorf("refusing to follow a redirect from https to %s", req.URL.Redacted())
+	}
+	return nil
+}
+
+func (d *Downloader) partialPath() string {
+	return d.filePath + PartialSuffix
+}
+
+func (d *Downloader) metaPath() string {
+	return d.filePath + metaSuffix
+}
+
+// Download runs attempts until the file is complete, the retries are used up
+// or the context is cancelled. The .partial file is kept on failure. A
+// segmented download falls back to a single stream when the server does not
+// serve ranges, a single stream partial is finished as one.
+func (d *Downloader) Download() error {
+	d.dropForeignPartial()
+	meter := newMeter(d.options.OnProgress)
+	if d.options.MinSpeed <= 0 {
+		return d.download(meter)
+	}
+	ctx, cancel := context.WithCancelCause(*d.ctx)
+	defer cancel(nil)
+	go watchSpeed(ctx, cancel, meter, d.options.MinSpeed, d.options.SlowWindow)
+	watched := *d
+	watched.ctx = &ctx
+	err := watched.download(meter)
+	if errors.Is(context.Cause(ctx), ErrSlow) && (*d.ctx).Err() == nil {
+		return fmt.Errorf("download %s: %w, under %s/s for %s", d.url, ErrSlow, utils.ByteCountDecimal(int64(d.options.MinSpeed)), d.options.SlowWindow)
+	}
+	return err
+}
+
+// retryWait is the pause before an attempt, doubling up to 30 seconds.
+func retryWait(attempt int) time.Duration {
+	wait := time.Duration(1<<uint(attempt-1)) * time.Second
+	if wait > 30*time.Second {
+		wait = 30 * time.Second
+	}
+	return wait
+}
+
+// watchSpeed cancels ctx with ErrSlow when a whole window moves fewer bytes
+// than the minimum speed allows.
+func watchSpeed(ctx context.Context, cancel context.CancelCauseFunc, meter *meter, minSpeed float64, window time.Duration) {
+	ticker := time.NewTicker(window)
+	defer ticker.Stop()
+	last := meter.count()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current := meter.count()
+			// a download that started over is measured from its new start
+			if current >= last && float64(current-last)/window.Seconds() < minSpeed {
+				cancel(ErrSlow)
+				return
+			}
+			last = current
+		}
+	}
+}
+
+func (d *Downloader) download(meter *meter) error {
+	if d.options.Segments > 1 && (fileSize(d.partialPath()) == 0 || len(d.readMeta().Segments) > 0) {
+		err := d.downloadSegmented(meter)
+		if err == nil || (*d.ctx).Err() != nil {
+			return err
+		}
+		if !errors.Is(err, errNoRanges) && !errors.Is(err, errRestart) {
+			return fmt.Errorf("download %s: %w", d.url, err)
+		}
+		if errors.Is(err, errRestart) {
+			logrus.LogrusLoggerWithContext(d.ctx).Warnf("download %s: the file changed on the server, starting over", d.url)
+		}
+		// the single stream starts from scratch
+		d.discard()
+	}
+	var err error
+	for attempt := 0; attempt <= d.options.Retries; attempt++ {
+		if attempt > 0 {
+			wait := retryWait(attempt)
+			logrus.LogrusLoggerWithContext(d.ctx).Warnf("download %s: %s, retrying in %s", d.url, err.Error(), wait)
+			select {
+			case <-(*d.ctx).Done():
+				return (*d.ctx).Err()
+			case <-time.After(wait):
+			}
+		}
+		err = d.attempt(meter)
+		if err == nil {
+			meter.finish()
+			if errComplete := d.complete(meter); errComplete != nil {
+				return fmt.Errorf("download %s: %w", d.url, errComplete)
+			}
+			return nil
+		}
+		if (*d.ctx).Err() != nil {
+			return (*d.ctx).Err()
+		}
+		var errStatus statusError
+		if errors.As(err, &errStatus) && !errStatus.retryable() {
+			return fmt.Errorf("download %s: %s", d.url, err.Error())
+		}
+	}
+	return fmt.Errorf("download %s: %s", d.url, err.Error())
+}
+
+func (d *Downloader) attempt(meter *meter) error {
+	offset := fileSize(d.partialPath())
+	meta := d.readMeta()
+	attemptCtx, cancel := context.WithCancel(*d.ctx)
+	defer cancel()
+	req, errReq := http.NewRequestWithContext(attemptCtx, http.MethodGet, d.url, nil)
+	if errReq != nil {
+		return statusError{code: http.StatusBadRequest, status: errReq.Error()}
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		// the server sends the whole file when it changed since the partial
+		if validator := meta.validator(); validator != "" {
+			req.Header.Set("If-Range", validator)
+		}
+	}
+	resp, errResp := d.client.Do(req)
+	if errResp != nil {
+		return errResp
+	}
+	defer resp.Body.Close()
+
+	total := int64(-1)
+	switch resp.StatusCode {
+	case http.StatusOK:
+		offset = 0
+		total = resp.ContentLength
+	case http.StatusPartialContent:
+		start, size, errRange := parseContentRange(resp.Header.Get("Content-Range"))
+		if errRange != nil || start != offset {
+			_ = os.Remove(d.partialPath())
+			return errRestart
+		}
+		total = size
+	case http.StatusRequestedRangeNotSatisfiable:
+		// the partial may already hold the whole file
+		if _, size, errRange := parseContentRange(resp.Header.Get("Content-Range")); errRange == nil && size == offset {
+			meter.start(offset, size)
+			return nil
+		}
+		_ = os.Remove(d.partialPath())
+		return errRestart
+	default:
+		return statusError{code: resp.StatusCode, status: resp.Status}
+	}
+	if final := resp.Request.URL.String(); final != d.url {
+		logrus.LogrusLoggerWithContext(d.ctx).Debugf("download %s redirected to %s", d.url, resp.Request.URL.Redacted())
+	}
+	d.writeMeta(partialMeta{
+		Url:          d.url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	})
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if offset == 0 {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	}
+	file, errOpen := os.OpenFile(d.partialPath(), flags, 0644)
+	if errOpen != nil {
+		return statusError{code: http.StatusInternalServerError, status: errOpen.Error()}
+	}
+	defer file.Close()
+	meter.start(offset, total)
+
+	// a stalled body cancels the attempt, every read pushes the deadline
+	idle := time.AfterFunc(d.options.IdleTimeout, cancel)
+	defer idle.Stop()
+	written, errCopy := io.Copy(file, &meteredReader{reader: resp.Body, meter: meter, idle: idle, timeout: d.options.IdleTimeout})
+	if errCopy != nil {
+		if attemptCtx.Err() != nil && (*d.ctx).Err() == nil {
+			return fmt.Errorf("no data for %s", d.options.IdleTimeout)
+		}
+		return errCopy
+	}
+	if total >= 0 && offset+written != total {
+		return fmt.Errorf("connection closed after %d of %d bytes", offset+written, total)
+	}
+	return file.Sync()
+}
+
+// complete checks the finished .partial against the size the server
+// announced and the expected digest, then moves it over the target file. A
+// file failing a check is deleted, resuming it would keep the bad bytes.
+func (d *Downloader) complete(meter *meter) error {
+	if total := meter.size(); total >= 0 {
+		if size := fileSize(d.partialPath()); size != total {
+			d.discard()
+			return fmt.Errorf("got %d bytes, the server announced %d", size, total)
+		}
+	}
+	if d.options.Sha256 != "" {
+		actual, err := fileSha256(d.partialPath())
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(actual, d.options.Sha256) {
+			d.discard()
+			return fmt.Errorf("%w, expected sha256 %s, got %s", ErrChecksum, strings.ToLower(d.options.Sha256), actual)
+		}
+	}
+	if err := os.Rename(d.partialPath(), d.filePath); err != nil {
+		return err
+	}
+	_ = os.Remove(d.metaPath())
+	return nil
+}
+
+// discard deletes the .partial and its state.
+func (d *Downloader) discard() {
+	_ = os.Remove(d.partialPath())
+	_ = os.Remove(d.metaPath())
+}
+
+// PartialUrl tells whether a download into filePath was interrupted and which
+// url it was fetching, empty when its state is missing.
+func PartialUrl(filePath string) (string, bool) {
+	_, errPartial := os.Stat(filePath + PartialSuffix)
+	_, errMeta := os.Stat(filePath + metaSuffix)
+	if errPartial != nil && errMeta != nil {
+		return "", false
+	}
+	return (&Downloader{filePath: filePath}).readMeta().Url, true
+}
+
+// dropForeignPartial deletes a .partial left by a download of another url.
+func (d *Downloader) dropForeignPartial() {
+	if meta := d.readMeta(); meta.Url != d.url {
+		d.discard()
+	}
+}
+
+func (d *Downloader) readMeta() partialMeta {
+	var meta partialMeta
+	data, err := os.ReadFile(d.metaPath())
+	if err == nil {
+		_ = json.Unmarshal(data, &meta)
+	}
+	return meta
+}
+
+func (d *Downloader) writeMeta(meta partialMeta) {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return
+	}
+	if err := utils.WriteFileAtomic(d.metaPath(), data, 0644); err != nil {
+		logrus.LogrusLoggerWithContext(d.ctx).Warnf("save download state: %s", err.Error())
+	}
+}
+
+// parseContentRange reads "bytes 100-199/200" or "bytes */200", the size is
+// -1 when the server writes "*".
+func parseContentRange(value string) (int64, int64, error) {
+	spec, found := strings.CutPrefix(strings.TrimSpace(value), "bytes ")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid content range %q", value)
+	}
+	span, sizeText, found := strings.Cut(spec, "/")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid content range %q", value)
+	}
+	size := int64(-1)
+	if sizeText != "*" {
+		parsed, err := strconv.ParseInt(sizeText, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid content range %q", value)
+		}
+		size = parsed
+	}
+	if span == "*" {
+		return 0, size, nil
+	}
+	startText, _, _ := strings.Cut(span, "-")
+	start, err := strconv.ParseInt(startText, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid content range %q", value)
+	}
+	return start, size, nil
+}
+
+func fileSha256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
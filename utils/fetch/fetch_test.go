@@ -0,0 +1,274 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"ytdlp/helpers/logrus"
+	"ytdlp/utils"
+)
+
+// TestMain keeps the log of the retries in a throwaway home dir.
+func TestMain(m *testing.M) {
+	home, err := os.MkdirTemp("", "ytdlp-fetch")
+	if err != nil {
+		panic(err)
+	}
+	if err := utils.SetHomeDir(home); err != nil {
+		panic(err)
+	}
+	logrus.InitLogrusLoggerWithConsole(nil)
+	code := m.Run()
+	_ = os.RemoveAll(home)
+	os.Exit(code)
+}
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		value     string
+		wantStart int64
+		wantSize  int64
+		wantErr   bool
+	}{
+		{value: "bytes 100-199/200", wantStart: 100, wantSize: 200},
+		{value: " bytes 0-0/1 ", wantStart: 0, wantSize: 1},
+		{value: "bytes 5-9/*", wantStart: 5, wantSize: -1},
+		{value: "bytes */200", wantStart: 0, wantSize: 200},
+		{value: "", wantErr: true},
+		{value: "items 0-1/2", wantErr: true},
+		{value: "bytes 0-1", wantErr: true},
+		{value: "bytes 0-1/x", wantErr: true},
+		{value: "bytes x-1/2", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			start, size, err := parseContentRange(test.value)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("parseContentRange(%q) error = %v, wantErr %v", test.value, err, test.wantErr)
+			}
+			if !test.wantErr && (start != test.wantStart || size != test.wantSize) {
+				t.Errorf("parseContentRange(%q) = %d, %d, want %d, %d", test.value, start, size, test.wantStart, test.wantSize)
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name  string
+		size  int64
+		count int
+		want  []segment
+	}{
+		{name: "even", size: 100, count: 4, want: []segment{{Start: 0, End: 24}, {Start: 25, End: 49}, {Start: 50, End: 74}, {Start: 75, End: 99}}},
+		{name: "last takes the remainder", size: 10, count: 3, want: []segment{{Start: 0, End: 2}, {Start: 3, End: 5}, {Start: 6, End: 9}}},
+		{name: "one segment", size: 7, count: 1, want: []segment{{Start: 0, End: 6}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := split(test.size, test.count)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("split(%d, %d) = %+v, want %+v", test.size, test.count, got, test.want)
+			}
+			covered := int64(0)
+			for _, s := range got {
+				covered += s.size()
+			}
+			if covered != test.size {
+				t.Errorf("segments cover %d bytes, want %d", covered, test.size)
+			}
+		})
+	}
+}
+
+// testContent is the file every test server hands out.
+var testContent = bytes.Repeat([]byte("0123456789abcdef"), 4096)
+
+// rangeLog records the Range header of every request a test server saw.
+type rangeLog struct {
+	mu     sync.Mutex
+	ranges []string
+}
+
+func (l *rangeLog) add(r *http.Request) {
+	l.mu.Lock()
+	l.ranges = append(l.ranges, r.Header.Get("Range"))
+	l.mu.Unlock()
+}
+
+func (l *rangeLog) list() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.ranges...)
+}
+
+func download(t *testing.T, url string, filePath string, options Options) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := NewDownloader(&ctx, url, filePath, options).Download(); err != nil {
+		t.Fatalf("Download() = %v", err)
+	}
+	got, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, testContent) {
+		t.Errorf("downloaded %d bytes that differ from the %d served", len(got), len(testContent))
+	}
+	if _, err := os.Stat(filePath + PartialSuffix); !os.IsNotExist(err) {
+		t.Errorf("partial file left behind: %v", err)
+	}
+	if _, err := os.Stat(filePath + metaSuffix); !os.IsNotExist(err) {
+		t.Errorf("partial state left behind: %v", err)
+	}
+}
+
+// writePartial leaves the state of an interrupted single stream download.
+func writePartial(t *testing.T, url string, filePath string, content []byte, etag string) {
+	t.Helper()
+	if err := os.WriteFile(filePath+PartialSuffix, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	d := &Downloader{ctx: &ctx, url: url, filePath: filePath}
+	d.writeMeta(partialMeta{Url: url, ETag: etag})
+}
+
+func TestDownloadResumes(t *testing.T) {
+	var log rangeLog
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.add(r)
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(testContent))
+	}))
+	defer server.Close()
+	filePath := filepath.Join(t.TempDir(), "file")
+	writePartial(t, server.URL, filePath, testContent[:1000], `"v1"`)
+
+	download(t, server.URL, filePath, Options{})
+	if got := log.list(); !reflect.DeepEqual(got, []string{"bytes=1000-"}) {
+		t.Errorf("requested ranges %q, want one resume from 1000", got)
+	}
+}
+
+func TestDownloadWithoutContentLength(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// flushing before the end makes the body chunked, with no length
+		for chunk := 0; chunk < len(testContent); chunk += 4096 {
+			_, _ = w.Write(testContent[chunk : chunk+4096])
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer server.Close()
+	filePath := filepath.Join(t.TempDir(), "file")
+
+	var totals []int64
+	download(t, server.URL, filePath, Options{OnProgress: func(progress Progress) { totals = append(totals, progress.Total) }})
+	// the total is only known once the body ended
+	if len(totals) == 0 || totals[len(totals)-1] != int64(len(testContent)) {
+		t.Errorf("progress totals %v, want the final one to be %d", totals, len(testContent))
+	}
+	for _, total := range totals[:len(totals)-1] {
+		if total != -1 {
+			t.Errorf("progress total %d before the end, want -1 for an unknown length", total)
+		}
+	}
+}
+
+func TestDownloadServerIgnoresRanges(t *testing.T) {
+	var log rangeLog
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.add(r)
+		_, _ = w.Write(testContent)
+	}))
+	defer server.Close()
+
+	t.Run("partial is replaced", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "file")
+		// bytes that do not belong to the file would corrupt it if appended
+		writePartial(t, server.URL, filePath, bytes.Repeat([]byte("x"), 1000), "")
+		download(t, server.URL, filePath, Options{})
+	})
+	t.Run("segments fall back to one stream", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "file")
+		download(t, server.URL, filePath, Options{Segments: 4, MinSegmentSize: 1024})
+	})
+	if got := log.list(); len(got) < 2 || got[0] != "bytes=1000-" {
+		t.Errorf("requested ranges %q, want the resume to be asked for first", got)
+	}
+}
+
+func TestDownloadRetriesFailedProbe(t *testing.T) {
+	var log rangeLog
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.add(r)
+		if len(log.list()) == 1 {
+			// a dropped connection, not a server without ranges
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			_ = conn.Close()
+			return
+		}
+		http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(testContent))
+	}))
+	defer server.Close()
+	filePath := filepath.Join(t.TempDir(), "file")
+
+	download(t, server.URL, filePath, Options{Segments: 4, MinSegmentSize: 1024})
+	got := log.list()
+	if len(got) != 6 || got[0] != "bytes=0-0" || got[1] != "bytes=0-0" {
+		t.Errorf("requested ranges %q, want the probe twice and then 4 segments", got)
+	}
+}
+
+func TestDownloadChecksum(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(testContent))
+	}))
+	defer server.Close()
+	sum := sha256.Sum256(testContent)
+	digest := hex.EncodeToString(sum[:])
+
+	for _, segments := range []int{1, 4} {
+		t.Run(fmt.Sprintf("%d segments", segments), func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "file")
+			download(t, server.URL, filePath, Options{Segments: segments, MinSegmentSize: 1024, Sha256: strings.ToUpper(digest)})
+
+			other := filepath.Join(t.TempDir(), "other")
+			ctx := context.Background()
+			wrong := strings.Repeat("0", 64)
+			err := NewDownloader(&ctx, server.URL, other, Options{Segments: segments, MinSegmentSize: 1024, Sha256: wrong}).Download()
+			if !errors.Is(err, ErrChecksum) {
+				t.Fatalf("Download() = %v, want ErrChecksum", err)
+			}
+			for _, leftover := range []string{other, other + PartialSuffix, other + metaSuffix} {
+				if _, errStat := os.Stat(leftover); !os.IsNotExist(errStat) {
+					t.Errorf("%s kept after a checksum mismatch", filepath.Base(leftover))
+				}
+			}
+		})
+	}
+}
+
+func TestPartialUrl(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "video.mp4")
+	if _, partial := PartialUrl(filePath); partial {
+		t.Error("PartialUrl() reports a partial before any download")
+	}
+	writePartial(t, "https://a.b/a/video.mp4", filePath, []byte("x"), "")
+	if owner, partial := PartialUrl(filePath); !partial || owner != "https://a.b/a/video.mp4" {
+		t.Errorf("PartialUrl() = %q, %v, want the url of the interrupted download", owner, partial)
+	}
+}
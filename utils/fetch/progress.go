@@ -0,0 +1,130 @@
+package fetch
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+const progressInterval = 500 * time.Millisecond
+
+type Progress struct {
+	Downloaded int64
+	// Total is -1 when the server does not send a length
+	Total int64
+	// Speed is in bytes per second
+	Speed float64
+	// Eta is zero when the total or the speed is unknown
+	Eta time.Duration
+}
+
+// Percent is 0 for downloads of unknown length.
+func (p Progress) Percent() float64 {
+	if p.Total <= 0 {
+		return 0
+	}
+	return float64(p.Downloaded) / float64(p.Total) * 100
+}
+
+// meter counts the bytes of all attempts and reports them at most every
+// progressInterval, the speed is smoothed so a burst does not swing the eta.
+type meter struct {
+	mu         sync.Mutex
+	onProgress func(Progress)
+	downloaded int64
+	total      int64
+	speed      float64
+	lastBytes  int64
+	lastAt     time.Time
+}
+
+func newMeter(onProgress func(Progress)) *meter {
+	return &meter{
+		onProgress: onProgress,
+		total:      -1,
+	}
+}
+
+// start resets the count to what the partial file already holds.
+func (m *meter) start(offset int64, total int64) {
+	m.mu.Lock()
+	m.downloaded = offset
+	m.total = total
+	m.lastBytes = offset
+	m.lastAt = time.Now()
+	m.mu.Unlock()
+	m.report(true)
+}
+
+func (m *meter) add(n int) {
+	m.mu.Lock()
+	m.downloaded += int64(n)
+	m.mu.Unlock()
+	m.report(false)
+}
+
+func (m *meter) count() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.downloaded
+}
+
+// size is the total the server announced, -1 when unknown.
+func (m *meter) size() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.total
+}
+
+func (m *meter) finish() {
+	m.mu.Lock()
+	if m.total < 0 {
+		m.total = m.downloaded
+	}
+	m.mu.Unlock()
+	m.report(true)
+}
+
+func (m *meter) report(force bool) {
+	m.mu.Lock()
+	now := time.Now()
+	elapsed := now.Sub(m.lastAt)
+	if !force && elapsed < progressInterval {
+		m.mu.Unlock()
+		return
+	}
+	if elapsed > 0 && m.downloaded > m.lastBytes {
+		current := float64(m.downloaded-m.lastBytes) / elapsed.Seconds()
+		if m.speed == 0 {
+			m.speed = current
+		} else {
+			m.speed = 0.3*current + 0.7*m.speed
+		}
+	}
+	m.lastBytes = m.downloaded
+	m.lastAt = now
+	progress := Progress{Downloaded: m.downloaded, Total: m.total, Speed: m.speed}
+	if m.total > 0 && m.speed > 0 && m.total > m.downloaded {
+		progress.Eta = time.Duration(float64(m.total-m.downloaded) / m.speed * float64(time.Second))
+	}
+	m.mu.Unlock()
+	if m.onProgress != nil {
+		m.onProgress(progress)
+	}
+}
+
+type meteredReader struct {
+	reader  io.Reader
+	meter   *meter
+	idle    *time.Timer
+	timeout time.Duration
+}
+
+func (r *meteredReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.idle.Reset(r.timeout)
+		r.meter.add(n)
+	}
+	return n, err
+}
@@ -0,0 +1,351 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"ytdlp/helpers/logrus"
+)
+
+var (
+	// errNoRanges sends the download down the single stream path
+	errNoRanges = errors.New("the server does not support range requests")
+)
+
+// segment is an inclusive byte span of the file, Done counts the bytes of it
+// already written.
+type segment struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+	Done  int64 `json:"done"`
+}
+
+func (s segment) size() int64 {
+	return s.End - s.Start + 1
+}
+
+// segmented is the state shared by the workers of one download.
+type segmented struct {
+	d     *Downloader
+	file  *os.File
+	meter *meter
+	meta  partialMeta
+
+	mu      sync.Mutex
+	savedAt time.Time
+}
+
+// downloadSegmented fetches the file over parallel range requests into a
+// preallocated .partial file, each worker writes its span in place so the
+// spans need no joining afterwards. errNoRanges means nothing was written.
+func (d *Downloader) downloadSegmented(meter *meter) error {
+	probe, errProbe := d.probeRetrying()
+	if errProbe != nil {
+		return errProbe
+	}
+	if probe.Size < d.options.MinSegmentSize*2 {
+		return errNoRanges
+	}
+	meta := d.readMeta()
+	if !sameFile(meta, probe) || fileSize(d.partialPath()) != probe.Size || len(meta.Segments) == 0 {
+		meta = probe
+		meta.Segments = split(probe.Size, d.options.Segments)
+		if err := d.preallocate(probe.Size); err != nil {
+			return err
+		}
+	}
+	file, errOpen := os.OpenFile(d.partialPath(), os.O_WRONLY, 0644)
+	if errOpen != nil {
+		return errOpen
+	}
+	state := &segmented{d: d, file: file, meter: meter, meta: meta}
+	state.save(true)
+
+	downloaded := int64(0)
+	for _, s := range meta.Segments {
+		downloaded += s.Done
+	}
+	meter.start(downloaded, meta.Size)
+	logrus.LogrusLoggerWithContext(d.ctx).Debugf("download %s in %d segments, %d bytes done", d.url, len(meta.Segments), downloaded)
+
+	workerCtx, cancel := context.WithCancel(*d.ctx)
+	defer cancel()
+	errs := make(chan error, len(meta.Segments))
+	var wg sync.WaitGroup
+	for index := range meta.Segments {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			if err := state.run(workerCtx, index); err != nil {
+				errs <- err
+				// one failed span fails the download, the rest stop early
+				cancel()
+			}
+		}(index)
+	}
+	wg.Wait()
+	close(errs)
+	state.save(true)
+	errClose := file.Close()
+
+	var err error
+	for errWorker := range errs {
+		// the cancellation of the other workers is not the cause
+		if err == nil || errors.Is(err, context.Canceled) {
+			err = errWorker
+		}
+	}
+	if err != nil {
+		if errors.Is(err, errRestart) {
+			d.discard()
+		}
+		if (*d.ctx).Err() != nil {
+			return (*d.ctx).Err()
+		}
+		return err
+	}
+	if errClose != nil {
+		return errClose
+	}
+	meter.finish()
+	return d.complete(meter)
+}
+
+// run fetches what is left of one segment, retrying on its own.
+func (s *segmented) run(ctx context.Context, index int) error {
+	var err error
+	for attempt := 0; attempt <= s.d.options.Retries; attempt++ {
+		if s.remaining(index) == 0 {
+			return nil
+		}
+		if attempt > 0 {
+			wait := retryWait(attempt)
+			logrus.LogrusLoggerWithContext(s.d.ctx).Warnf("download %s segment %d: %s, retrying in %s", s.d.url, index, err.Error(), wait)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+		err = s.attempt(ctx, index)
+		if err == nil {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var errStatus statusError
+		if errors.Is(err, errRestart) || (errors.As(err, &errStatus) && !errStatus.retryable()) {
+			return err
+		}
+	}
+	if s.remaining(index) == 0 {
+		return nil
+	}
+	return fmt.Errorf("segment %d: %s", index, err.Error())
+}
+
+func (s *segmented) attempt(ctx context.Context, index int) error {
+	s.mu.Lock()
+	current := s.meta.Segments[index]
+	s.mu.Unlock()
+	from := current.Start + current.Done
+
+	attemptCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	req, errReq := http.NewRequestWithContext(attemptCtx, http.MethodGet, s.d.url, nil)
+	if errReq != nil {
+		return statusError{code: http.StatusBadRequest, status: errReq.Error()}
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", from, current.End))
+	if validator := s.meta.validator(); validator != "" {
+		req.Header.Set("If-Range", validator)
+	}
+	resp, errResp := s.d.client.Do(req)
+	if errResp != nil {
+		return errResp
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if start, _, err := parseContentRange(resp.Header.Get("Content-Range")); err != nil || start != from {
+			return errRestart
+		}
+	case http.StatusOK:
+		// If-Range failed, the file changed since the partial was started
+		return errRestart
+	default:
+		return statusError{code: resp.StatusCode, status: resp.Status}
+	}
+
+	idle := time.AfterFunc(s.d.options.IdleTimeout, cancel)
+	defer idle.Stop()
+	reader := &meteredReader{reader: resp.Body, meter: s.meter, idle: idle, timeout: s.d.options.IdleTimeout}
+	buffer := make([]byte, 64<<10)
+	offset := from
+	for offset <= current.End {
+		want := int64(len(buffer))
+		if left := current.End - offset + 1; left < want {
+			want = left
+		}
+		n, errRead := reader.Read(buffer[:want])
+		if n > 0 {
+			if _, errWrite := s.file.WriteAt(buffer[:n], offset); errWrite != nil {
+				return statusError{code: http.StatusInternalServerError, status: errWrite.Error()}
+			}
+			offset += int64(n)
+			s.advance(index, int64(n))
+		}
+		if errRead == io.EOF {
+			break
+		}
+		if errRead != nil {
+			if attemptCtx.Err() != nil && ctx.Err() == nil {
+				return fmt.Errorf("no data for %s", s.d.options.IdleTimeout)
+			}
+			return errRead
+		}
+	}
+	if offset <= current.End {
+		return fmt.Errorf("connection closed after %d of %d bytes", offset-current.Start, current.size())
+	}
+	return nil
+}
+
+func (s *segmented) remaining(index int) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.meta.Segments[index].size() - s.meta.Segments[index].Done
+}
+
+func (s *segmented) advance(index int, n int64) {
+	s.mu.Lock()
+	s.meta.Segments[index].Done += n
+	s.mu.Unlock()
+	s.save(false)
+}
+
+// save writes the segment state so a later call resumes each span, at most
+// once a second unless forced.
+func (s *segmented) save(force bool) {
+	s.mu.Lock()
+	if !force && time.Since(s.savedAt) < time.Second {
+		s.mu.Unlock()
+		return
+	}
+	s.savedAt = time.Now()
+	meta := s.meta
+	meta.Segments = append([]segment(nil), s.meta.Segments...)
+	s.mu.Unlock()
+	// the bytes must be on disk before the state claims them
+	_ = s.file.Sync()
+	s.d.writeMeta(meta)
+}
+
+// probeRetrying asks again when the probe fails on the way, like the
+// attempts of a single stream do.
+func (d *Downloader) probeRetrying() (partialMeta, error) {
+	var err error
+	for attempt := 0; attempt <= d.options.Retries; attempt++ {
+		if attempt > 0 {
+			wait := retryWait(attempt)
+			logrus.LogrusLoggerWithContext(d.ctx).Warnf("download %s probe: %s, retrying in %s", d.url, err.Error(), wait)
+			select {
+			case <-(*d.ctx).Done():
+				return partialMeta{}, (*d.ctx).Err()
+			case <-time.After(wait):
+			}
+		}
+		var probe partialMeta
+		probe, err = d.probe()
+		if err == nil || errors.Is(err, errNoRanges) || (*d.ctx).Err() != nil {
+			return probe, err
+		}
+		var errStatus statusError
+		if errors.As(err, &errStatus) && !errStatus.retryable() {
+			return probe, err
+		}
+	}
+	return partialMeta{}, err
+}
+
+// probe asks for the first byte to learn the size and whether ranges work.
+func (d *Downloader) probe() (partialMeta, error) {
+	reqCtx, cancel := context.WithTimeout(*d.ctx, time.Minute)
+	defer cancel()
+	req, errReq := http.NewRequestWithContext(reqCtx, http.MethodGet, d.url, nil)
+	if errReq != nil {
+		return partialMeta{}, statusError{code: http.StatusBadRequest, status: errReq.Error()}
+	}
+	req.Header.Set("Range", "bytes=0-0")
+	resp, errResp := d.client.Do(req)
+	if errResp != nil {
+		return partialMeta{}, errResp
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		return partialMeta{}, errNoRanges
+	}
+	_, size, errRange := parseContentRange(resp.Header.Get("Content-Range"))
+	if errRange != nil || size <= 0 {
+		return partialMeta{}, errNoRanges
+	}
+	return partialMeta{
+		Url:          d.url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Size:         size,
+	}, nil
+}
+
+func (d *Downloader) preallocate(size int64) error {
+	file, err := os.OpenFile(d.partialPath(), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if err := file.Truncate(size); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// split cuts size into count spans, the last one takes the remainder.
+func split(size int64, count int) []segment {
+	length := size / int64(count)
+	segments := make([]segment, 0, count)
+	for i := 0; i < count; i++ {
+		start := int64(i) * length
+		end := start + length - 1
+		if i == count-1 {
+			end = size - 1
+		}
+		segments = append(segments, segment{Start: start, End: end})
+	}
+	return segments
+}
+
+// sameFile tells whether the saved state describes what the server has now.
+func sameFile(saved partialMeta, current partialMeta) bool {
+	if saved.Url != current.Url || saved.Size != current.Size {
+		return false
+	}
+	if saved.ETag != "" || current.ETag != "" {
+		return saved.ETag == current.ETag
+	}
+	return saved.LastModified == current.LastModified
+}
+
+// validator is the If-Range value, weak etags are not allowed there.
+func (m partialMeta) validator() string {
+	if m.ETag != "" && !strings.HasPrefix(m.ETag, "W/") {
+		return m.ETag
+	}
+	return m.LastModified
+}
@@ -0,0 +1,223 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+const (
+	// HomeEnv points the home dir somewhere else than ~/.ytdlp
+	HomeEnv = "YTDLP_HOME"
+	// PortableMarker next to the executable keeps all data beside it
+	PortableMarker  = "ytdlp.portable"
+	portableDirName = "ytdlp-data"
+)
+
+type HomeMode string
+
+const (
+	HomeModeDefault  HomeMode = "default"
+	HomeModeFlag     HomeMode = "flag"
+	HomeModeEnv      HomeMode = "env"
+	HomeModePortable HomeMode = "portable"
+)
+
+var (
+	homeMu       sync.Mutex
+	homeOverride string
+	homeResolved string
+	homeMode     HomeMode
+)
+
+// SetHomeDir overrides the home dir for this process, it has to be called
+// before anything reads GetHomeDir, e.g. the logger.
+func SetHomeDir(dir string) error {
+	if strings.TrimSpace(dir) == "" {
+		return errors.New("the home dir cannot be empty")
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	homeMu.Lock()
+	defer homeMu.Unlock()
+	homeOverride = abs
+	homeResolved = ""
+	return nil
+}
+
+// ResolveHome returns the home dir and where it came from: the override set
+// by SetHomeDir, HomeEnv, the portable marker and ~/.ytdlp, in that order.
+func ResolveHome() (string, HomeMode) {
+	homeMu.Lock()
+	defer homeMu.Unlock()
+	if homeOverride != "" {
+		return homeOverride, HomeModeFlag
+	}
+	if homeResolved != "" {
+		return homeResolved, homeMode
+	}
+	homeResolved, homeMode = DefaultHomeDir(), HomeModeDefault
+	if dir := strings.TrimSpace(os.Getenv(HomeEnv)); dir != "" {
+		if abs, err := filepath.Abs(dir); err == nil {
+			homeResolved, homeMode = abs, HomeModeEnv
+		}
+	} else if dir, err := PortableHomeDir(); err == nil && IsPortable() {
+		homeResolved, homeMode = dir, HomeModePortable
+	}
+	return homeResolved, homeMode
+}
+
+func GetHomeDir() string {
+	dir, _ := ResolveHome()
+	return dir
+}
+
+// HomeArgs start another process of this binary on the same home dir, for
+// command lines registered with the system. They are empty when the home dir
+// is found without help.
+func HomeArgs() []string {
+	dir, mode := ResolveHome()
+	if mode != HomeModeFlag && mode != HomeModeEnv {
+		return nil
+	}
+	return HomeArgsFor(dir)
+}
+
+// HomeArgsFor is HomeArgs for a home dir other than the current one.
+func HomeArgsFor(dir string) []string {
+	if dir == DefaultHomeDir() && !IsPortable() {
+		return nil
+	}
+	return []string{"--home", dir}
+}
+
+// DefaultHomeDir is ~/.ytdlp, the home dir when nothing else is set.
+func DefaultHomeDir() string {
+	userHomeDir, _ := os.UserHomeDir()
+	return filepath.Join(userHomeDir, ".ytdlp")
+}
+
+// PortableHomeDir is the data dir beside the executable.
+func PortableHomeDir() (string, error) {
+	base, err := portableBase()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, portableDirName), nil
+}
+
+// IsPortable tells whether the portable marker sits next to the executable.
+func IsPortable() bool {
+	base, err := portableBase()
+	if err != nil {
+		return false
+	}
+	info, errStat := os.Stat(filepath.Join(base, PortableMarker))
+	return errStat == nil && !info.IsDir()
+}
+
+// EnablePortable writes the portable marker, the next start uses
+// PortableHomeDir.
+func EnablePortable() error {
+	base, err := portableBase()
+	if err != nil {
+		return err
+	}
+	content := "Data of this copy lives in " + portableDirName + ", remove this file to use ~/.ytdlp again.\n"
+	return os.WriteFile(filepath.Join(base, PortableMarker), []byte(content), 0644)
+}
+
+// portableBase is the folder of the executable, on macOS the folder holding
+// the .app bundle so the marker can sit next to what the user sees.
+func portableBase() (string, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	if resolved, errLink := filepath.EvalSymlinks(executable); errLink == nil {
+		executable = resolved
+	}
+	dir := filepath.Dir(executable)
+	if runtime.GOOS == "darwin" && filepath.Base(dir) == "MacOS" && strings.HasSuffix(filepath.Dir(filepath.Dir(dir)), ".app") {
+		return filepath.Dir(filepath.Dir(filepath.Dir(dir))), nil
+	}
+	return dir, nil
+}
+
+// MigrateHome moves the home dir from to to, which must not exist or be
+// empty. A rename is tried first, across disks the files are copied and the
+// source is removed once everything arrived. Sockets are left behind, they
+// belong to a process that is gone.
+func MigrateHome(from string, to string) error {
+	from, errFrom := filepath.Abs(from)
+	if errFrom != nil {
+		return errFrom
+	}
+	to, errTo := filepath.Abs(to)
+	if errTo != nil {
+		return errTo
+	}
+	info, errStat := os.Stat(from)
+	if errStat != nil {
+		return fmt.Errorf("nothing to move: %s", errStat.Error())
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a folder", from)
+	}
+	if from == to || isInside(to, from) || isInside(from, to) {
+		return fmt.Errorf("%s and %s overlap", from, to)
+	}
+	if entries, err := os.ReadDir(to); err == nil {
+		if len(entries) > 0 {
+			return fmt.Errorf("%s is not empty", to)
+		}
+		if err := os.Remove(to); err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	if err := CheckOrCreateDir(filepath.Dir(to)); err != nil {
+		return err
+	}
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+	if err := copyHome(from, to); err != nil {
+		_ = os.RemoveAll(to)
+		return err
+	}
+	return os.RemoveAll(from)
+}
+
+func copyHome(from string, to string) error {
+	return filepath.WalkDir(from, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, errRel := filepath.Rel(from, path)
+		if errRel != nil {
+			return errRel
+		}
+		target := filepath.Join(to, rel)
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(target, 0755)
+		case entry.Type().IsRegular():
+			return CopyFile(path, target)
+		}
+		return nil
+	})
+}
+
+func isInside(path string, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
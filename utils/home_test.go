@@ -0,0 +1,161 @@
+package utils
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func writeHome(t *testing.T, dir string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, "resources"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"settings.json": "{}", "resources/installed.json": "[]"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func checkHome(t *testing.T, dir string) {
+	t.Helper()
+	for name, want := range map[string]string{"settings.json": "{}", "resources/installed.json": "[]"} {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v, want %q", name, got, err, want)
+		}
+	}
+}
+
+func TestMigrateHome(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, from string, to string)
+		to      func(from string, to string) string
+		wantErr string
+	}{
+		{name: "new folder"},
+		{name: "nested new folder", to: func(from string, to string) string { return filepath.Join(to, "a", "b") }},
+		{
+			name: "empty target",
+			prepare: func(t *testing.T, from string, to string) {
+				if err := os.MkdirAll(to, 0755); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "target with files",
+			prepare: func(t *testing.T, from string, to string) {
+				writeHome(t, to)
+			},
+			wantErr: "not empty",
+		},
+		{name: "target inside the source", to: func(from string, to string) string { return filepath.Join(from, "inner") }, wantErr: "overlap"},
+		{name: "source inside the target", to: func(from string, to string) string { return filepath.Dir(from) }, wantErr: "overlap"},
+		{name: "same folder", to: func(from string, to string) string { return from }, wantErr: "overlap"},
+		{
+			name: "missing source",
+			prepare: func(t *testing.T, from string, to string) {
+				if err := os.RemoveAll(from); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: "nothing to move",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			base := t.TempDir()
+			from := filepath.Join(base, "old", ".ytdlp")
+			to := filepath.Join(base, "new")
+			writeHome(t, from)
+			if test.prepare != nil {
+				test.prepare(t, from, to)
+			}
+			if test.to != nil {
+				to = test.to(from, to)
+			}
+			err := MigrateHome(from, to)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("MigrateHome() = %v, want an error about %q", err, test.wantErr)
+				}
+				if _, errStat := os.Stat(from); errStat == nil {
+					checkHome(t, from)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MigrateHome() = %v", err)
+			}
+			checkHome(t, to)
+			if _, errStat := os.Stat(from); !os.IsNotExist(errStat) {
+				t.Errorf("source still exists: %v", errStat)
+			}
+		})
+	}
+}
+
+func TestCopyHomeSkipsSockets(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets")
+	}
+	// socket paths are short, t.TempDir can be too long for them
+	from, err := os.MkdirTemp("", "ytdlp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(from)
+	writeHome(t, from)
+	listener, errListen := net.Listen("unix", filepath.Join(from, "ytdlp.sock"))
+	if errListen != nil {
+		t.Skip(errListen)
+	}
+	defer listener.Close()
+	to := filepath.Join(t.TempDir(), "new")
+	if err := copyHome(from, to); err != nil {
+		t.Fatalf("copyHome() = %v", err)
+	}
+	checkHome(t, to)
+	if _, errStat := os.Lstat(filepath.Join(to, "ytdlp.sock")); !os.IsNotExist(errStat) {
+		t.Errorf("socket was copied: %v", errStat)
+	}
+}
+
+func TestIsInside(t *testing.T) {
+	dir := filepath.Join(string(filepath.Separator), "home", "user", ".ytdlp")
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: filepath.Join(dir, "logs"), want: true},
+		{path: filepath.Join(dir, "a", "b"), want: true},
+		{path: dir},
+		{path: filepath.Dir(dir)},
+		{path: dir + "-old"},
+		{path: filepath.Join(filepath.Dir(dir), "..data")},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			if got := isInside(test.path, dir); got != test.want {
+				t.Errorf("isInside(%q, %q) = %v, want %v", test.path, dir, got, test.want)
+			}
+		})
+	}
+}
+
+func TestHomeArgsFor(t *testing.T) {
+	other := filepath.Join(t.TempDir(), "data")
+	if got, want := HomeArgsFor(other), []string{"--home", other}; !reflect.DeepEqual(got, want) {
+		t.Errorf("HomeArgsFor(%q) = %q, want %q", other, got, want)
+	}
+	if got := HomeArgsFor(DefaultHomeDir()); got != nil && !IsPortable() {
+		t.Errorf("HomeArgsFor(default) = %q, want none", got)
+	}
+}
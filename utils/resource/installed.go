@@ -0,0 +1,147 @@
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"ytdlp/utils"
+)
+
+const installedVersion = 1
+
+// Installed records what the app put into the dir of a resource.
+type Installed struct {
+	Key     string `json:"key"`
+	Version string `json:"version"`
+	// Url is where the download came from, Sha256 its digest, empty when the
+	// download was installed unverified
+	Url         string    `json:"url"`
+	Mirror      string    `json:"mirror"`
+	Sha256      string    `json:"sha256"`
+	InstalledAt time.Time `json:"installedAt"`
+	// Executables maps executable names to paths relative to the resource dir
+	Executables map[string]string `json:"executables"`
+	// Previous is the install an update replaced, kept for rollbacks
+	Previous *Installed `json:"previous,omitempty"`
+}
+
+type installedFile struct {
+	Version   int                  `json:"version"`
+	Resources map[string]Installed `json:"resources"`
+}
+
+var installedMu sync.Mutex
+
+func GetInstalledPath() string {
+	return filepath.Join(utils.GetResourceDir(), "installed.json")
+}
+
+// LoadInstalled reads the manifest of installed resources, a missing file is
+// an empty manifest.
+func LoadInstalled() (map[string]Installed, error) {
+	installedMu.Lock()
+	defer installedMu.Unlock()
+	return readInstalled()
+}
+
+// RecordInstalled adds or replaces the entry of a resource.
+func RecordInstalled(entry Installed) error {
+	installedMu.Lock()
+	defer installedMu.Unlock()
+	entries, err := readInstalled()
+	if err != nil {
+		// a broken manifest is rebuilt, discovery finds what it lost
+		entries = map[string]Installed{}
+	}
+	entries[entry.Key] = entry
+	return writeInstalled(entries)
+}
+
+// ForgetInstalled drops the entry of a resource.
+func ForgetInstalled(key string) error {
+	installedMu.Lock()
+	defer installedMu.Unlock()
+	entries, err := readInstalled()
+	if err != nil {
+		return err
+	}
+	if _, ok := entries[key]; !ok {
+		return nil
+	}
+	delete(entries, key)
+	return writeInstalled(entries)
+}
+
+// Installed returns the manifest entry of r.
+func (r Resource) Installed() (Installed, bool) {
+	entries, err := LoadInstalled()
+	if err != nil {
+		return Installed{}, false
+	}
+	entry, ok := entries[r.Key]
+	return entry, ok
+}
+
+// Discover scans the resource dir for the executable and its extras, the
+// result is keyed by executable name with paths relative to the dir.
+func (r Resource) Discover() map[string]string {
+	found := map[string]string{}
+	wanted := map[string]string{}
+	for _, name := range append([]string{r.Executable}, r.Extras...) {
+		wanted[fileName(name)] = name
+	}
+	// the manifest layout is checked first so the walk is only a fallback
+	if artifact, err := r.Artifact(); err == nil && artifact.Binary != "" {
+		if isFile(filepath.Join(r.Dir(), filepath.FromSlash(artifact.Binary))) {
+			found[r.Executable] = artifact.Binary
+		}
+	}
+	_ = filepath.WalkDir(r.Dir(), func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return filepath.SkipDir
+		}
+		name, ok := wanted[entry.Name()]
+		if entry.IsDir() || !ok {
+			return nil
+		}
+		if _, done := found[name]; !done {
+			if rel, errRel := filepath.Rel(r.Dir(), path); errRel == nil {
+				found[name] = filepath.ToSlash(rel)
+			}
+		}
+		if len(found) == len(wanted) {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found
+}
+
+func readInstalled() (map[string]Installed, error) {
+	data, err := os.ReadFile(GetInstalledPath())
+	if os.IsNotExist(err) {
+		return map[string]Installed{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file installedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("read %s: %s", GetInstalledPath(), err.Error())
+	}
+	if file.Resources == nil {
+		file.Resources = map[string]Installed{}
+	}
+	return file.Resources, nil
+}
+
+func writeInstalled(entries map[string]Installed) error {
+	data, err := json.MarshalIndent(installedFile{Version: installedVersion, Resources: entries}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(GetInstalledPath(), data, 0644)
+}
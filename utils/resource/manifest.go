@@ -0,0 +1,187 @@
+package resource
+
+import (
+	"fmt"
+	"path"
+	"runtime"
+	"strings"
+	"ytdlp/utils"
+	"ytdlp/utils/settings"
+)
+
+const (
+	ArchiveZip   = "zip"
+	ArchiveTarXz = "tar.xz"
+	ArchiveTarGz = "tar.gz"
+)
+
+// Artifact is the download of a resource for one platform.
+type Artifact struct {
+	Url string `json:"url"`
+	// Archive is ArchiveZip, ArchiveTarXz, ArchiveTarGz or empty for a bare
+	// executable
+	Archive string `json:"archive"`
+	// Binary is the path of the executable inside the install dir, when empty
+	// the dir is searched for it
+	Binary string `json:"binary"`
+	// Sha256 is the expected digest of the download, SumsUrl points at the
+	// upstream checksum list to look it up in instead
+	Sha256  string `json:"sha256,omitempty"`
+	SumsUrl string `json:"sumsUrl,omitempty"`
+	// Mirror is the settings entry the urls were taken from
+	Mirror string `json:"mirror,omitempty"`
+}
+
+type Resource struct {
+	Key   string `json:"key"`
+	Title string `json:"title"`
+	// Executable is the file name without the windows .exe suffix
+	Executable string `json:"executable"`
+	// Extras are other executables shipped with it, recorded on install
+	Extras []string `json:"extras,omitempty"`
+	// VersionArgs make the binary print its version, a system copy only
+	// counts when they succeed
+	VersionArgs []string `json:"versionArgs"`
+	// Artifacts are keyed by utils.GetPlatform
+	Artifacts map[string]Artifact `json:"artifacts"`
+}
+
+const (
+	ffmpegBuild = "ffmpeg-N-116752-g507c2a5774"
+	ffmpegBase  = "https://github.com/BtbN/FFmpeg-Builds/releases/download/autobuild-2024-08-20-13-02/"
+	ffmpegSums  = ffmpegBase + "checksums.sha256"
+	ytDlpBase   = "https://github.com/yt-dlp/yt-dlp/releases/download/2024.05.27/"
+	ytDlpSums   = ytDlpBase + "SHA2-256SUMS"
+)
+
+var FFmpeg = Resource{
+	Key:         "ffmpeg",
+	Title:       "FFmpeg",
+	Executable:  "ffmpeg",
+	Extras:      []string{"ffprobe"},
+	VersionArgs: []string{"-version"},
+	Artifacts: map[string]Artifact{
+		"windows_x64": {
+			Url:     ffmpegBase + ffmpegBuild + "-win64-gpl.zip",
+			Archive: ArchiveZip,
+			Binary:  ffmpegBuild + "-win64-gpl/bin/ffmpeg.exe",
+			SumsUrl: ffmpegSums,
+		},
+		// windows on arm runs the x64 build through emulation
+		"windows_arm64": {
+			Url:     ffmpegBase + ffmpegBuild + "-win64-gpl.zip",
+			Archive: ArchiveZip,
+			Binary:  ffmpegBuild + "-win64-gpl/bin/ffmpeg.exe",
+			SumsUrl: ffmpegSums,
+		},
+		"linux_x64": {
+			Url:     ffmpegBase + ffmpegBuild + "-linux64-gpl.tar.xz",
+			Archive: ArchiveTarXz,
+			Binary:  ffmpegBuild + "-linux64-gpl/bin/ffmpeg",
+			SumsUrl: ffmpegSums,
+		},
+		"linux_arm64": {
+			Url:     ffmpegBase + ffmpegBuild + "-linuxarm64-gpl.tar.xz",
+			Archive: ArchiveTarXz,
+			Binary:  ffmpegBuild + "-linuxarm64-gpl/bin/ffmpeg",
+			SumsUrl: ffmpegSums,
+		},
+		// the mac builds publish no checksum list and carry no digest, they
+		// are only installed with Resources.AllowUnverified, otherwise the
+		// setup leaves ffmpeg to the system, e.g. Homebrew
+		"darwin_x64": {
+			Url:     "https://evermeet.cx/ffmpeg/ffmpeg-7.0.2.zip",
+			Archive: ArchiveZip,
+			Binary:  "ffmpeg",
+		},
+		"darwin_arm64": {
+			Url:     "https://www.osxexperts.net/ffmpeg7arm.zip",
+			Archive: ArchiveZip,
+			Binary:  "ffmpeg",
+		},
+	},
+}
+
+var YtDlp = Resource{
+	Key:         "yt-dlp",
+	Title:       "yt-dlp",
+	Executable:  "yt-dlp",
+	VersionArgs: []string{"--version"},
+	Artifacts: map[string]Artifact{
+		"windows_x64":   {Url: ytDlpBase + "yt-dlp.exe", Binary: "yt-dlp.exe", SumsUrl: ytDlpSums},
+		"windows_arm64": {Url: ytDlpBase + "yt-dlp.exe", Binary: "yt-dlp.exe", SumsUrl: ytDlpSums},
+		"linux_x64":     {Url: ytDlpBase + "yt-dlp_linux", Binary: "yt-dlp", SumsUrl: ytDlpSums},
+		"linux_arm64":   {Url: ytDlpBase + "yt-dlp_linux_aarch64", Binary: "yt-dlp", SumsUrl: ytDlpSums},
+		// the macos build is universal
+		"darwin_x64":   {Url: ytDlpBase + "yt-dlp_macos", Binary: "yt-dlp", SumsUrl: ytDlpSums},
+		"darwin_arm64": {Url: ytDlpBase + "yt-dlp_macos", Binary: "yt-dlp", SumsUrl: ytDlpSums},
+	},
+}
+
+func List() []Resource {
+	return []Resource{FFmpeg, YtDlp}
+}
+
+func Get(key string) (Resource, error) {
+	for _, r := range List() {
+		if r.Key == key {
+			return r, nil
+		}
+	}
+	return Resource{}, fmt.Errorf("unknown resource %q", key)
+}
+
+// Artifact returns the download for this platform, a url set in the
+// settings replaces the one of the manifest.
+func (r Resource) Artifact() (Artifact, error) {
+	if override, sha256 := r.override(); override != "" {
+		return Artifact{Url: override, Archive: archiveOf(override), Sha256: sha256}, nil
+	}
+	artifact, ok := r.Artifacts[utils.GetPlatform()]
+	if !ok {
+		return Artifact{}, fmt.Errorf("%s has no build for %s, install it on the system path instead", r.Title, utils.GetPlatform())
+	}
+	return artifact, nil
+}
+
+// FileName is the executable name on this platform.
+func (r Resource) FileName() string {
+	return fileName(r.Executable)
+}
+
+func fileName(executable string) string {
+	if runtime.GOOS == "windows" {
+		return executable + ".exe"
+	}
+	return executable
+}
+
+// Custom tells whether the settings replace the download url.
+func (r Resource) Custom() bool {
+	override, _ := r.override()
+	return override != ""
+}
+
+func (r Resource) override() (string, string) {
+	current := settings.Current().Resources
+	switch r.Key {
+	case FFmpeg.Key:
+		return current.FFmpegUrl, current.FFmpegSha256
+	case YtDlp.Key:
+		return current.YtDlpUrl, current.YtDlpSha256
+	}
+	return "", ""
+}
+
+func archiveOf(url string) string {
+	name := strings.ToLower(path.Base(strings.SplitN(url, "?", 2)[0]))
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return ArchiveZip
+	case strings.HasSuffix(name, ".tar.xz"):
+		return ArchiveTarXz
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return ArchiveTarGz
+	}
+	return ""
+}
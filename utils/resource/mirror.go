@@ -0,0 +1,69 @@
+package resource
+
+import (
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"ytdlp/utils/settings"
+)
+
+// Candidates returns the artifact of this platform once per configured
+// mirror, in the order they are tried. A url set in the settings is the only
+// candidate, mirrors carry the release files under their upstream names
+// while the checksums are always taken from upstream.
+func (r Resource) Candidates() ([]Artifact, error) {
+	artifact, err := r.Artifact()
+	if err != nil {
+		return nil, err
+	}
+	if override, _ := r.override(); override != "" {
+		artifact.Mirror = override
+		return []Artifact{artifact}, nil
+	}
+	candidates := make([]Artifact, 0)
+	for _, mirror := range settings.Current().Resources.Mirrors {
+		candidates = append(candidates, artifact.onMirror(mirror))
+	}
+	return candidates, nil
+}
+
+func (a Artifact) onMirror(mirror string) Artifact {
+	a.Mirror = mirror
+	if mirror == settings.MirrorUpstream {
+		return a
+	}
+	// a mirror serving its own checksum list could vouch for any file
+	a.Url = mirrorFile(mirror, path.Base(a.Url))
+	return a
+}
+
+// mirrorFile joins a file name onto an http(s) base url or a local folder,
+// file:// urls become plain paths.
+func mirrorFile(mirror string, name string) string {
+	if parsed, err := url.Parse(mirror); err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") {
+		parsed.Path = strings.TrimRight(parsed.Path, "/") + "/" + name
+		return parsed.String()
+	}
+	return filepath.Join(LocalPath(mirror), name)
+}
+
+// LocalPath returns the file path of a local mirror entry or file:// url, an
+// empty string for anything fetched over http.
+func LocalPath(location string) string {
+	if filepath.IsAbs(location) {
+		return location
+	}
+	if parsed, err := url.Parse(location); err == nil && parsed.Scheme == "file" {
+		return filepath.FromSlash(parsed.Path)
+	}
+	return ""
+}
+
+// FileName is the name of the download, the one its checksum is listed under.
+func (a Artifact) FileName() string {
+	if local := LocalPath(a.Url); local != "" {
+		return filepath.Base(local)
+	}
+	return path.Base(strings.SplitN(a.Url, "?", 2)[0])
+}
@@ -0,0 +1,229 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"ytdlp/utils"
+	"ytdlp/utils/settings"
+)
+
+type Source string
+
+const (
+	// SourceManaged is a copy the app downloaded into the resource dir
+	SourceManaged Source = settings.SourceManaged
+	SourceSystem  Source = settings.SourceSystem
+	// SourceCustom is the path set for the resource in the settings
+	SourceCustom  Source = settings.SourceCustom
+	SourceMissing Source = "missing"
+)
+
+type Resolution struct {
+	Key    string `json:"key"`
+	Title  string `json:"title"`
+	Path   string `json:"path"`
+	Source Source `json:"source"`
+	// Version is the recorded version of a managed copy
+	Version string `json:"version"`
+	// Rejected lists the sources tried before the one picked
+	Rejected []Rejection `json:"rejected,omitempty"`
+}
+
+// Rejection is a source passed over, Path is empty when it had no binary and
+// set when the binary failed the version check.
+type Rejection struct {
+	Source Source `json:"source"`
+	Path   string `json:"path"`
+}
+
+func (r Rejection) String() string {
+	if r.Path == "" {
+		return fmt.Sprintf("%s: not found", r.Source)
+	}
+	return fmt.Sprintf("%s: %s does not run", r.Source, r.Path)
+}
+
+type versionCheck struct {
+	modTime time.Time
+	size    int64
+	ok      bool
+}
+
+var (
+	systemMu    sync.Mutex
+	systemPaths = map[string]string{}
+	checkedMu   sync.Mutex
+	checked     = map[string]versionCheck{}
+)
+
+// Dir is where the app installs the resource.
+func (r Resource) Dir() string {
+	return filepath.Join(utils.GetResourceDir(), r.Key)
+}
+
+// ManagedPath returns the installed executable or an empty string. The
+// manifest of installed resources is asked first, installs it does not know
+// about are discovered by scanning the resource dir.
+func (r Resource) ManagedPath() string {
+	if entry, ok := r.Installed(); ok {
+		if rel, ok := entry.Executables[r.Executable]; ok {
+			if binary := filepath.Join(r.Dir(), filepath.FromSlash(rel)); isFile(binary) {
+				return binary
+			}
+		}
+	}
+	if rel, ok := r.Discover()[r.Executable]; ok {
+		return filepath.Join(r.Dir(), filepath.FromSlash(rel))
+	}
+	return ""
+}
+
+// SystemPath returns a working executable from the system path or an empty
+// string, the lookup is done once per process.
+func (r Resource) SystemPath() string {
+	systemMu.Lock()
+	defer systemMu.Unlock()
+	if path, ok := systemPaths[r.Key]; ok {
+		return path
+	}
+	path, err := exec.LookPath(r.FileName())
+	if err != nil || !r.Works(path) {
+		path = ""
+	}
+	systemPaths[r.Key] = path
+	return path
+}
+
+// Works runs the binary with its version arguments. The result is kept
+// until the file changes, so resolving before every download stays cheap.
+func (r Resource) Works(path string) bool {
+	info, errStat := os.Stat(path)
+	if errStat != nil || info.IsDir() {
+		return false
+	}
+	checkedMu.Lock()
+	last, ok := checked[path]
+	checkedMu.Unlock()
+	if ok && last.modTime.Equal(info.ModTime()) && last.size == info.Size() {
+		return last.ok
+	}
+	_, err := r.Version(path)
+	checkedMu.Lock()
+	checked[path] = versionCheck{modTime: info.ModTime(), size: info.Size(), ok: err == nil}
+	checkedMu.Unlock()
+	return err == nil
+}
+
+// Version returns the first line the binary prints for its version
+// arguments, e.g. "2024.05.27" for yt-dlp.
+func (r Resource) Version(path string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path, r.VersionArgs...)
+	utils.HideWindow(cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s %s: %s", r.Title, strings.Join(r.VersionArgs, " "), err.Error())
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	return strings.TrimSpace(line), nil
+}
+
+// Sources returns the resolution order and custom path of r from the
+// settings.
+func (r Resource) Sources() settings.ResourceSource {
+	current := settings.Current().Resources
+	switch r.Key {
+	case FFmpeg.Key:
+		return current.FFmpeg
+	case YtDlp.Key:
+		return current.YtDlp
+	}
+	return settings.ResourceSource{Order: []string{settings.SourceManaged, settings.SourceSystem}}
+}
+
+// Allows tells whether source is in the resolution order of r.
+func (r Resource) Allows(source Source) bool {
+	for _, entry := range r.Sources().Order {
+		if Source(entry) == source {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve walks the sources in the order set for r and picks the first
+// binary that passes the version check.
+func (r Resource) Resolve() Resolution {
+	resolution := Resolution{Key: r.Key, Title: r.Title, Source: SourceMissing}
+	sources := r.Sources()
+	for _, entry := range sources.Order {
+		source := Source(entry)
+		path := ""
+		switch source {
+		case SourceManaged:
+			path = r.ManagedPath()
+		case SourceSystem:
+			path = r.SystemPath()
+		case SourceCustom:
+			path = sources.CustomPath
+		}
+		if path == "" || !r.Works(path) {
+			resolution.Rejected = append(resolution.Rejected, Rejection{Source: source, Path: path})
+			continue
+		}
+		resolution.Path, resolution.Source = path, source
+		if source == SourceManaged {
+			if installed, ok := r.Installed(); ok {
+				resolution.Version = installed.Version
+			}
+		}
+		return resolution
+	}
+	return resolution
+}
+
+// Reasons describes the rejected sources for messages.
+func (r Resolution) Reasons() string {
+	reasons := make([]string, 0, len(r.Rejected))
+	for _, rejection := range r.Rejected {
+		reasons = append(reasons, rejection.String())
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// Path is the executable to run, the bare file name when nothing was found
+// so the error names the missing tool.
+func (r Resource) Path() string {
+	if path := r.Resolve().Path; path != "" {
+		return path
+	}
+	return r.FileName()
+}
+
+func ResolveAll() []Resolution {
+	resolutions := make([]Resolution, 0)
+	for _, r := range List() {
+		resolutions = append(resolutions, r.Resolve())
+	}
+	return resolutions
+}
+
+func FFmpegPath() string {
+	return FFmpeg.Path()
+}
+
+func YtDlpPath() string {
+	return YtDlp.Path()
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,65 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// migrations upgrade the raw json of one schema version to the next, keyed
+// by the version they start from. They only ever move forward.
+var migrations = map[int]func(raw map[string]interface{}) error{
+	// files written by hand before versioning carry no version field, the
+	// first schema kept their layout
+	0: func(raw map[string]interface{}) error {
+		return nil
+	},
+	// version 1 stored the windows download urls as defaults, version 2
+	// keeps only urls the user changed since each platform has its own
+	1: func(raw map[string]interface{}) error {
+		resources, ok := raw["resources"].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		legacy := map[string]string{
+			"ffmpegUrl": "https://github.com/BtbN/FFmpeg-Builds/releases/download/autobuild-2024-08-20-13-02/ffmpeg-N-116752-g507c2a5774-win64-gpl.zip",
+			"ytDlpUrl":  "https://github.com/yt-dlp/yt-dlp/releases/download/2024.05.27/yt-dlp.exe",
+		}
+		for key, value := range legacy {
+			if resources[key] == value {
+				resources[key] = ""
+			}
+		}
+		return nil
+	},
+}
+
+// migrate brings raw up to CurrentVersion and reports whether anything
+// changed. A file from a newer build is read as is and left untouched.
+func migrate(raw map[string]interface{}) (int, bool, error) {
+	version := 0
+	if value, ok := raw["version"].(float64); ok {
+		version = int(value)
+	}
+	if version >= CurrentVersion {
+		return version, false, nil
+	}
+	for v := version; v < CurrentVersion; v++ {
+		migration, ok := migrations[v]
+		if !ok {
+			return version, false, fmt.Errorf("no settings migration from version %d", v)
+		}
+		if err := migration(raw); err != nil {
+			return version, false, fmt.Errorf("migrate settings from version %d: %s", v, err.Error())
+		}
+		raw["version"] = v + 1
+	}
+	return CurrentVersion, true, nil
+}
+
+func readVersion(data []byte) int {
+	var header struct {
+		Version int `json:"version"`
+	}
+	_ = json.Unmarshal(data, &header)
+	return header.Version
+}
@@ -0,0 +1,170 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const (
+	legacyFFmpegUrl = "https://github.com/BtbN/FFmpeg-Builds/releases/download/autobuild-2024-08-20-13-02/ffmpeg-N-116752-g507c2a5774-win64-gpl.zip"
+	legacyYtDlpUrl  = "https://github.com/yt-dlp/yt-dlp/releases/download/2024.05.27/yt-dlp.exe"
+)
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name         string
+		raw          string
+		wantVersion  int
+		wantMigrated bool
+		wantRaw      string
+	}{
+		{
+			name:         "unversioned file",
+			raw:          `{"preset":"720p"}`,
+			wantVersion:  CurrentVersion,
+			wantMigrated: true,
+			wantRaw:      `{"preset":"720p","version":2}`,
+		},
+		{
+			name:         "version 1 drops the windows default urls",
+			raw:          `{"version":1,"resources":{"ffmpegUrl":"` + legacyFFmpegUrl + `","ytDlpUrl":"` + legacyYtDlpUrl + `"}}`,
+			wantVersion:  CurrentVersion,
+			wantMigrated: true,
+			wantRaw:      `{"version":2,"resources":{"ffmpegUrl":"","ytDlpUrl":""}}`,
+		},
+		{
+			name:         "version 1 keeps urls the user changed",
+			raw:          `{"version":1,"resources":{"ffmpegUrl":"https://mirror.example/ffmpeg.zip"}}`,
+			wantVersion:  CurrentVersion,
+			wantMigrated: true,
+			wantRaw:      `{"version":2,"resources":{"ffmpegUrl":"https://mirror.example/ffmpeg.zip"}}`,
+		},
+		{
+			name:         "version 1 without resources",
+			raw:          `{"version":1}`,
+			wantVersion:  CurrentVersion,
+			wantMigrated: true,
+			wantRaw:      `{"version":2}`,
+		},
+		{
+			name:        "current version is untouched",
+			raw:         `{"version":2,"resources":{"ffmpegUrl":"` + legacyFFmpegUrl + `"}}`,
+			wantVersion: CurrentVersion,
+			wantRaw:     `{"version":2,"resources":{"ffmpegUrl":"` + legacyFFmpegUrl + `"}}`,
+		},
+		{
+			name:        "newer version is read as is",
+			raw:         `{"version":9,"future":true}`,
+			wantVersion: 9,
+			wantRaw:     `{"version":9,"future":true}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			raw := map[string]interface{}{}
+			if err := json.Unmarshal([]byte(test.raw), &raw); err != nil {
+				t.Fatal(err)
+			}
+			version, migrated, err := migrate(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if version != test.wantVersion || migrated != test.wantMigrated {
+				t.Errorf("migrate() = %d, %v, want %d, %v", version, migrated, test.wantVersion, test.wantMigrated)
+			}
+			// round trip so numbers compare the same way on both sides
+			want := map[string]interface{}{}
+			if err := json.Unmarshal([]byte(test.wantRaw), &want); err != nil {
+				t.Fatal(err)
+			}
+			data, _ := json.Marshal(raw)
+			got := map[string]interface{}{}
+			_ = json.Unmarshal(data, &got)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("migrated json = %s, want %s", data, test.wantRaw)
+			}
+		})
+	}
+}
+
+func TestStoreReadMigrates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	legacy := `{"version":1,"preset":"720p","logLevel":"debug","resources":{"ffmpegUrl":"` + legacyFFmpegUrl + `"}}`
+	if err := os.WriteFile(path, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+	store := &Store{path: path}
+	got, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != CurrentVersion || got.Preset != "720p" || got.LogLevel != "debug" || got.Resources.FFmpegUrl != "" {
+		t.Errorf("Load() = %+v", got)
+	}
+	if !reflect.DeepEqual(got.Resources.FFmpeg, Defaults().Resources.FFmpeg) {
+		t.Errorf("missing fields did not keep their default: %+v", got.Resources.FFmpeg)
+	}
+	backup, errBackup := os.ReadFile(path + ".v1.bak")
+	if errBackup != nil || string(backup) != legacy {
+		t.Errorf("backup = %q, %v, want the version 1 file", backup, errBackup)
+	}
+	if data, _ := os.ReadFile(path); readVersion(data) != CurrentVersion {
+		t.Errorf("file on disk is still version %d", readVersion(data))
+	}
+}
+
+func TestStoreReadKeepsDefaults(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{name: "not json", content: `{`, wantErr: true},
+		{name: "invalid value", content: `{"version":2,"logLevel":"loud"}`, wantErr: true},
+		{name: "pinned channel without a version", content: `{"version":2,"updates":{"channel":"pinned","pinnedVersion":" "}}`, wantErr: true},
+		// decoding must not reuse the slices of the defaults handed back
+		{name: "invalid value after a slice", content: `{"version":2,"resources":{"ffmpeg":{"order":["custom"]}},"logLevel":"loud"}`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "settings.json")
+			if err := os.WriteFile(path, []byte(test.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := (&Store{path: path}).Load()
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if !reflect.DeepEqual(got.Resources, Defaults().Resources) || got.LogLevel != DefaultLogLevel {
+				t.Errorf("Load() = %+v, want the defaults", got)
+			}
+		})
+	}
+}
+
+func TestStoreUpdateKeepsNewerFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	newer := `{"version":9,"preset":"720p","future":{"keep":true}}`
+	if err := os.WriteFile(path, []byte(newer), 0644); err != nil {
+		t.Fatal(err)
+	}
+	store := &Store{path: path}
+	current, errLoad := store.Load()
+	if errLoad != nil {
+		t.Fatal(errLoad)
+	}
+	current.Preset = "best"
+	if _, err := store.Update(current); !errors.Is(err, ErrNewerSettings) {
+		t.Fatalf("Update() = %v, want ErrNewerSettings", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != newer {
+		t.Errorf("file = %s, want it untouched", data)
+	}
+	if got := store.Get(); got.Preset != "720p" {
+		t.Errorf("Get() = %+v after a refused update", got)
+	}
+}
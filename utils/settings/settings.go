@@ -0,0 +1,362 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"ytdlp/utils"
+)
+
+const (
+	// CurrentVersion is the schema written by this build, bump it together
+	// with a new entry in migrations.
+	CurrentVersion = 2
+
+	DefaultPreset   = "480p"
+	DefaultLogLevel = "info"
+
+	UpdateChannelStable  = "stable"
+	UpdateChannelNightly = "nightly"
+	UpdateChannelPinned  = "pinned"
+
+	DefaultUpdateEndpoint = "https://api.github.com"
+
+	// MirrorUpstream in the mirror list stands for the download url of the
+	// resource manifest, e.g. GitHub
+	MirrorUpstream = "upstream"
+	// DefaultMinSpeed is the transfer rate in bytes per second under which a
+	// mirror is given up when another one is left
+	DefaultMinSpeed = 50_000
+
+	// where a resource binary may come from, see ResourceSource
+	SourceManaged = "managed"
+	SourceSystem  = "system"
+	SourceCustom  = "custom"
+)
+
+// ErrNewerSettings is returned by Update when a newer build wrote the file.
+var ErrNewerSettings = errors.New("the settings were saved by a newer version of the app and cannot be changed here")
+
+var sha256Pattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+var logLevels = []string{"trace", "debug", "info", "warn", "error"}
+
+// Resources replace the download url of the resource manifest for this
+// platform, empty keeps the manifest one.
+type Resources struct {
+	FFmpegUrl string `json:"ffmpegUrl"`
+	YtDlpUrl  string `json:"ytDlpUrl"`
+	// the sha256 of a custom download, without it the download is refused
+	// unless AllowUnverified is set
+	FFmpegSha256 string `json:"ffmpegSha256"`
+	YtDlpSha256  string `json:"ytDlpSha256"`
+	// AllowUnverified installs downloads that have no checksum to check
+	AllowUnverified bool `json:"allowUnverified"`
+	// Mirrors are tried in order: MirrorUpstream, an http(s) base url or a
+	// local folder holding the release files, checksums still come from
+	// upstream
+	Mirrors []string `json:"mirrors"`
+	// MinSpeed in bytes per second, 0 never gives up on a slow mirror
+	MinSpeed int64          `json:"minSpeed"`
+	FFmpeg   ResourceSource `json:"ffmpeg"`
+	YtDlp    ResourceSource `json:"ytDlp"`
+}
+
+// ResourceSource picks where a binary comes from, the first source in Order
+// whose binary passes the version check wins.
+type ResourceSource struct {
+	// Order holds SourceManaged, SourceSystem and SourceCustom, leaving out
+	// SourceManaged means the app never downloads its own copy
+	Order []string `json:"order"`
+	// CustomPath is the absolute path of the binary for SourceCustom
+	CustomPath string `json:"customPath"`
+}
+
+type ControlApi struct {
+	// Enabled starts the local control api with the app
+	Enabled bool `json:"enabled"`
+	// Port 0 picks a free port on every start
+	Port int `json:"port"`
+}
+
+type Updates struct {
+	// Endpoint serves the GitHub releases api, point it at a mirror or a
+	// local mock server
+	Endpoint string `json:"endpoint"`
+	Channel  string `json:"channel"`
+	// PinnedVersion is the release tag the pinned channel installs
+	PinnedVersion string `json:"pinnedVersion"`
+}
+
+type Settings struct {
+	Version int `json:"version"`
+	// OutputDir is where downloads, recordings and replays are saved
+	OutputDir  string     `json:"outputDir"`
+	Preset     string     `json:"preset"`
+	LogLevel   string     `json:"logLevel"`
+	Resources  Resources  `json:"resources"`
+	ControlApi ControlApi `json:"controlApi"`
+	Updates    Updates    `json:"updates"`
+}
+
+func Defaults() Settings {
+	return Settings{
+		Version:   CurrentVersion,
+		OutputDir: utils.GetOutputDir(),
+		Preset:    DefaultPreset,
+		LogLevel:  DefaultLogLevel,
+		Resources: Resources{
+			Mirrors:  []string{MirrorUpstream},
+			MinSpeed: DefaultMinSpeed,
+			FFmpeg:   ResourceSource{Order: []string{SourceManaged, SourceSystem}},
+			YtDlp:    ResourceSource{Order: []string{SourceManaged, SourceSystem}},
+		},
+		Updates: Updates{
+			Endpoint: DefaultUpdateEndpoint,
+			Channel:  UpdateChannelStable,
+		},
+	}
+}
+
+// Validate checks the fields this package knows about, presets are checked
+// by the caller against the preset list.
+func (s Settings) Validate() error {
+	if s.OutputDir == "" || !filepath.IsAbs(s.OutputDir) {
+		return fmt.Errorf("output dir %q must be an absolute path", s.OutputDir)
+	}
+	if s.Preset == "" {
+		return errors.New("preset is required")
+	}
+	if !validLogLevel(s.LogLevel) {
+		return fmt.Errorf("log level %q must be one of %s", s.LogLevel, strings.Join(logLevels, ", "))
+	}
+	for name, value := range map[string]string{"ffmpeg": s.Resources.FFmpegUrl, "yt-dlp": s.Resources.YtDlpUrl} {
+		if value == "" {
+			continue
+		}
+		parsed, err := url.Parse(value)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("%s url %q must be an http(s) url", name, value)
+		}
+	}
+	for name, value := range map[string]string{"ffmpeg": s.Resources.FFmpegSha256, "yt-dlp": s.Resources.YtDlpSha256} {
+		if value != "" && !sha256Pattern.MatchString(value) {
+			return fmt.Errorf("%s sha256 %q must be 64 hex characters", name, value)
+		}
+	}
+	if len(s.Resources.Mirrors) == 0 {
+		return errors.New("at least one mirror is required")
+	}
+	for _, mirror := range s.Resources.Mirrors {
+		if err := validMirror(mirror); err != nil {
+			return err
+		}
+	}
+	if s.Resources.MinSpeed < 0 {
+		return errors.New("min speed cannot be negative")
+	}
+	for name, source := range map[string]ResourceSource{"ffmpeg": s.Resources.FFmpeg, "yt-dlp": s.Resources.YtDlp} {
+		if err := source.validate(name); err != nil {
+			return err
+		}
+	}
+	if s.ControlApi.Port < 0 || s.ControlApi.Port > 65535 {
+		return fmt.Errorf("control api port %d is out of range", s.ControlApi.Port)
+	}
+	if parsed, err := url.Parse(s.Updates.Endpoint); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("update endpoint %q must be an http(s) url", s.Updates.Endpoint)
+	}
+	switch s.Updates.Channel {
+	case UpdateChannelStable, UpdateChannelNightly:
+	case UpdateChannelPinned:
+		if strings.TrimSpace(s.Updates.PinnedVersion) == "" {
+			return errors.New("the pinned update channel needs a version")
+		}
+	default:
+		return fmt.Errorf("unknown update channel %q", s.Updates.Channel)
+	}
+	return nil
+}
+
+func (s ResourceSource) validate(name string) error {
+	if len(s.Order) == 0 {
+		return fmt.Errorf("%s needs at least one source", name)
+	}
+	seen := map[string]bool{}
+	for _, source := range s.Order {
+		switch source {
+		case SourceManaged, SourceSystem, SourceCustom:
+		default:
+			return fmt.Errorf("unknown %s source %q", name, source)
+		}
+		if seen[source] {
+			return fmt.Errorf("%s source %q is listed twice", name, source)
+		}
+		seen[source] = true
+	}
+	if s.CustomPath != "" && !filepath.IsAbs(s.CustomPath) {
+		return fmt.Errorf("%s custom path %q must be absolute", name, s.CustomPath)
+	}
+	if seen[SourceCustom] && s.CustomPath == "" {
+		return fmt.Errorf("the custom %s source needs a path", name)
+	}
+	return nil
+}
+
+func validMirror(mirror string) error {
+	if mirror == MirrorUpstream || filepath.IsAbs(mirror) {
+		return nil
+	}
+	parsed, err := url.Parse(mirror)
+	if err == nil && parsed.Scheme == "file" && parsed.Path != "" {
+		return nil
+	}
+	if err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != "" {
+		return nil
+	}
+	return fmt.Errorf("mirror %q must be %q, an http(s) url or an absolute folder path", mirror, MirrorUpstream)
+}
+
+func validLogLevel(level string) bool {
+	for _, known := range logLevels {
+		if level == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Store keeps the settings in a versioned json file under the home dir.
+type Store struct {
+	path string
+
+	mu       sync.Mutex
+	settings Settings
+	loaded   bool
+}
+
+var (
+	defaultStore     *Store
+	defaultStoreOnce sync.Once
+)
+
+func GetSettingsPath() string {
+	return filepath.Join(utils.GetHomeDir(), "settings.json")
+}
+
+func Default() *Store {
+	defaultStoreOnce.Do(func() {
+		defaultStore = &Store{path: GetSettingsPath()}
+	})
+	return defaultStore
+}
+
+// Current returns the settings of the default store.
+func Current() Settings {
+	return Default().Get()
+}
+
+// Get returns the stored settings, or the defaults when the file is missing
+// or unreadable so callers always get usable values.
+func (s *Store) Get() Settings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loaded {
+		s.settings, _ = s.read()
+		s.loaded = true
+	}
+	return s.settings
+}
+
+// Load reads the file again and reports why it could not be used, the
+// defaults are kept in that case.
+func (s *Store) Load() (Settings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	settings, err := s.read()
+	s.settings = settings
+	s.loaded = true
+	return settings, err
+}
+
+// Update validates and saves next, it returns the stored settings.
+func (s *Store) Update(next Settings) (Settings, error) {
+	next.Version = CurrentVersion
+	next.OutputDir = filepath.Clean(strings.TrimSpace(next.OutputDir))
+	if err := next.Validate(); err != nil {
+		return Settings{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// writing back a newer file would drop the fields this build does not
+	// know, so it is left read only
+	if data, err := os.ReadFile(s.path); err == nil {
+		if version := readVersion(data); version > CurrentVersion {
+			return Settings{}, fmt.Errorf("%w: the settings file has version %d, this build writes %d", ErrNewerSettings, version, CurrentVersion)
+		}
+	}
+	if err := s.write(next); err != nil {
+		return Settings{}, err
+	}
+	s.settings = next
+	s.loaded = true
+	return next, nil
+}
+
+func (s *Store) read() (Settings, error) {
+	defaults := Defaults()
+	data, errRead := os.ReadFile(s.path)
+	if os.IsNotExist(errRead) {
+		return defaults, nil
+	}
+	if errRead != nil {
+		return defaults, errRead
+	}
+	raw := map[string]interface{}{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return defaults, fmt.Errorf("read settings: %s", err.Error())
+	}
+	version, migrated, errMigrate := migrate(raw)
+	if errMigrate != nil {
+		return defaults, errMigrate
+	}
+	migratedData, errJson := json.Marshal(raw)
+	if errJson != nil {
+		return defaults, errJson
+	}
+	// fields missing from the file keep their default, a fresh copy since
+	// decoding reuses the backing arrays of slices
+	settings := Defaults()
+	if err := json.Unmarshal(migratedData, &settings); err != nil {
+		return defaults, fmt.Errorf("read settings: %s", err.Error())
+	}
+	settings.Version = version
+	if err := settings.Validate(); err != nil {
+		return defaults, fmt.Errorf("invalid settings: %s", err.Error())
+	}
+	if migrated {
+		// keep a copy of what the older build wrote before replacing it
+		_ = os.WriteFile(fmt.Sprintf("%s.v%d.bak", s.path, readVersion(data)), data, 0644)
+		if err := s.write(settings); err != nil {
+			return settings, err
+		}
+	}
+	return settings, nil
+}
+
+func (s *Store) write(settings Settings) error {
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := utils.CheckOrCreateDir(filepath.Dir(s.path)); err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(s.path, data, 0644)
+}
@@ -0,0 +1,289 @@
+package setup
+
+import (
+	archiveZip "archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+	"ytdlp/helpers/logrus"
+	"ytdlp/utils"
+	"ytdlp/utils/emit"
+	"ytdlp/utils/resource"
+	"ytdlp/utils/zip"
+)
+
+const (
+	BundleManifestName = "bundle.json"
+	bundleVersion      = 1
+)
+
+// BundleManifest sits at the root of a bundle zip next to one folder per
+// resource, every file of those folders is listed with its digest.
+type BundleManifest struct {
+	Version   int              `json:"version"`
+	Platform  string           `json:"platform"`
+	CreatedAt time.Time        `json:"createdAt"`
+	Resources []BundleResource `json:"resources"`
+}
+
+type BundleResource struct {
+	resource.Installed
+	// Files maps slash separated paths inside the resource folder to their
+	// sha256
+	Files map[string]string `json:"files"`
+}
+
+// ExportBundle zips the resources the app installed together with a
+// manifest, so a machine without network access can install them with
+// InstallBundle.
+func ExportBundle(ctx *context.Context, outputFile string) (BundleManifest, error) {
+	stage := filepath.Join(utils.GetTempDir(), fmt.Sprintf("bundle-export-%d", time.Now().UnixNano()))
+	defer func() {
+		_ = utils.CheckOrDeleteDir(stage)
+	}()
+	manifest := BundleManifest{
+		Version:   bundleVersion,
+		Platform:  utils.GetPlatform(),
+		CreatedAt: time.Now(),
+		Resources: make([]BundleResource, 0),
+	}
+	for _, r := range resource.List() {
+		if r.ManagedPath() == "" {
+			logrus.LogrusLoggerWithContext(ctx).Infof("%s is not installed by the app, leaving it out of the bundle", r.Title)
+			continue
+		}
+		entry, ok := r.Installed()
+		if !ok {
+			// installs from before the manifest are described from the disk
+			entry = resource.Installed{Key: r.Key, Executables: r.Discover()}
+			entry.Version, _ = r.Version(r.ManagedPath())
+		}
+		entry.Previous = nil
+		if err := utils.CopyDir(r.Dir(), filepath.Join(stage, r.Key)); err != nil {
+			return BundleManifest{}, err
+		}
+		files, err := bundleFiles(filepath.Join(stage, r.Key))
+		if err != nil {
+			return BundleManifest{}, err
+		}
+		manifest.Resources = append(manifest.Resources, BundleResource{Installed: entry, Files: files})
+	}
+	if len(manifest.Resources) == 0 {
+		return BundleManifest{}, errors.New("no resource is installed by the app, run the setup first")
+	}
+	data, errJson := json.MarshalIndent(manifest, "", "  ")
+	if errJson != nil {
+		return BundleManifest{}, errJson
+	}
+	if err := os.WriteFile(filepath.Join(stage, BundleManifestName), data, 0644); err != nil {
+		return BundleManifest{}, err
+	}
+	if err := zip.CompressFolder(ctx, stage, outputFile); err != nil {
+		_ = utils.CheckOrDeleteFile(outputFile)
+		return BundleManifest{}, err
+	}
+	logrus.LogrusLoggerWithContext(ctx).Infof("Resource bundle saved to %s", outputFile)
+	return manifest, nil
+}
+
+// InstallBundle installs the resources of a bundle made by ExportBundle
+// without touching the network. Every file is checked against the manifest
+// and every executable must run before the current install is replaced.
+func InstallBundle(ctx *context.Context, bundleFile string) (BundleManifest, error) {
+	manifest, errManifest := readBundleManifest(bundleFile)
+	if errManifest != nil {
+		return BundleManifest{}, errManifest
+	}
+	if manifest.Platform != utils.GetPlatform() {
+		return BundleManifest{}, fmt.Errorf("the bundle was made for %s, this machine is %s", manifest.Platform, utils.GetPlatform())
+	}
+	stage := filepath.Join(utils.GetTempDir(), fmt.Sprintf("bundle-import-%d", time.Now().UnixNano()))
+	defer func() {
+		_ = utils.CheckOrDeleteDir(stage)
+	}()
+	if err := utils.CheckOrCreateDir(stage); err != nil {
+		return BundleManifest{}, err
+	}
+	if err := zip.UnzipFile(ctx, bundleFile, stage); err != nil {
+		return BundleManifest{}, err
+	}
+	// everything is checked before the first resource is replaced
+	for _, entry := range manifest.Resources {
+		if err := checkBundleResource(stage, entry); err != nil {
+			return BundleManifest{}, err
+		}
+		r, _ := resource.Get(entry.Key)
+		unclaim, errClaim := claim(r)
+		if errClaim != nil {
+			return BundleManifest{}, errClaim
+		}
+		defer unclaim()
+	}
+	for _, entry := range manifest.Resources {
+		r, _ := resource.Get(entry.Key)
+		emitResource := emit.NewEmitResource(ctx, r.Key, r.Title)
+		emitResource.Start()
+		if err := placeBundleResource(stage, r, entry); err != nil {
+			emitResource.Error(err.Error())
+			return BundleManifest{}, err
+		}
+		emitResource.Stop()
+		logrus.LogrusLoggerWithContext(ctx).Infof("%s %s installed from %s", r.Title, entry.Version, bundleFile)
+	}
+	return manifest, nil
+}
+
+// readBundleManifest reads bundle.json and rejects entries that would land
+// outside the extraction folder.
+func readBundleManifest(bundleFile string) (BundleManifest, error) {
+	reader, errOpen := archiveZip.OpenReader(bundleFile)
+	if errOpen != nil {
+		return BundleManifest{}, fmt.Errorf("open bundle: %s", errOpen.Error())
+	}
+	defer reader.Close()
+	var manifestFile *archiveZip.File
+	for _, file := range reader.File {
+		if zip.CheckEntryName(file.Name) != nil {
+			return BundleManifest{}, fmt.Errorf("bundle entry %q is outside the bundle", file.Name)
+		}
+		if strings.ReplaceAll(file.Name, `\`, "/") == BundleManifestName {
+			manifestFile = file
+		}
+	}
+	if manifestFile == nil {
+		return BundleManifest{}, fmt.Errorf("the file is not a resource bundle, %s is missing", BundleManifestName)
+	}
+	content, errContent := manifestFile.Open()
+	if errContent != nil {
+		return BundleManifest{}, errContent
+	}
+	defer content.Close()
+	var manifest BundleManifest
+	if err := json.NewDecoder(io.LimitReader(content, 1<<20)).Decode(&manifest); err != nil {
+		return BundleManifest{}, fmt.Errorf("read %s: %s", BundleManifestName, err.Error())
+	}
+	if manifest.Version != bundleVersion {
+		return BundleManifest{}, fmt.Errorf("bundle version %d is not supported", manifest.Version)
+	}
+	if len(manifest.Resources) == 0 {
+		return BundleManifest{}, errors.New("the bundle holds no resource")
+	}
+	return manifest, nil
+}
+
+// checkBundleResource compares the extracted folder of a resource with the
+// manifest, then makes sure its executable runs.
+func checkBundleResource(stage string, entry BundleResource) error {
+	r, err := resource.Get(entry.Key)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(stage, r.Key)
+	files, errFiles := bundleFiles(dir)
+	if errFiles != nil {
+		return fmt.Errorf("%s is missing from the bundle", r.Title)
+	}
+	for name, expected := range entry.Files {
+		actual, ok := files[name]
+		if !ok {
+			return fmt.Errorf("%s of %s is missing from the bundle", name, r.Title)
+		}
+		if !strings.EqualFold(actual, expected) {
+			return fmt.Errorf("%s of %s failed the checksum check (expected %s, got %s)", name, r.Title, expected, actual)
+		}
+	}
+	for name := range files {
+		if _, ok := entry.Files[name]; !ok {
+			return fmt.Errorf("%s of %s is not listed in the bundle manifest", name, r.Title)
+		}
+	}
+	rel, ok := entry.Executables[r.Executable]
+	if !ok {
+		return fmt.Errorf("the bundle does not name the %s executable", r.Title)
+	}
+	for _, executable := range entry.Executables {
+		if _, listed := files[executable]; !listed {
+			return fmt.Errorf("executable %s of %s is not in the bundle", executable, r.Title)
+		}
+	}
+	if runtime.GOOS != "windows" {
+		// zip entries carry no file modes
+		for _, executable := range entry.Executables {
+			if err := os.Chmod(filepath.Join(dir, filepath.FromSlash(executable)), 0755); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := r.Version(filepath.Join(dir, filepath.FromSlash(rel))); err != nil {
+		return fmt.Errorf("%s from the bundle does not run: %s", r.Title, err.Error())
+	}
+	return nil
+}
+
+func placeBundleResource(stage string, r resource.Resource, entry BundleResource) error {
+	if err := resource.ForgetInstalled(r.Key); err != nil {
+		return err
+	}
+	if err := utils.CheckOrDeleteDir(r.Dir()); err != nil {
+		return err
+	}
+	if err := utils.CheckOrCreateDir(filepath.Dir(r.Dir())); err != nil {
+		return err
+	}
+	if err := os.Rename(filepath.Join(stage, r.Key), r.Dir()); err != nil {
+		return err
+	}
+	installed := entry.Installed
+	installed.Mirror = "bundle"
+	installed.InstalledAt = time.Now()
+	return resource.RecordInstalled(installed)
+}
+
+// bundleFiles lists the files under dir by slash separated path with their
+// digest. Leftovers of updates are not part of an install and are dropped.
+func bundleFiles(dir string) (map[string]string, error) {
+	files := map[string]string{}
+	err := filepath.WalkDir(dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		for _, suffix := range []string{previousSuffix, updateSuffix, ".swap"} {
+			if strings.HasSuffix(entry.Name(), suffix) {
+				return os.Remove(filePath)
+			}
+		}
+		rel, errRel := filepath.Rel(dir, filePath)
+		if errRel != nil {
+			return errRel
+		}
+		sum, errSum := fileSha256(filePath)
+		if errSum != nil {
+			return errSum
+		}
+		files[filepath.ToSlash(rel)] = sum
+		return nil
+	})
+	return files, err
+}
+
+// Summary is a one line description for logs and messages.
+func (m BundleManifest) Summary() string {
+	parts := make([]string, 0, len(m.Resources))
+	for _, entry := range m.Resources {
+		parts = append(parts, strings.TrimSpace(fmt.Sprintf("%s %s", entry.Key, entry.Version)))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,142 @@
+package setup
+
+import (
+	archiveZip "archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"ytdlp/utils/resource"
+)
+
+func writeBundle(t *testing.T, files map[string]string) string {
+	t.Helper()
+	bundleFile := filepath.Join(t.TempDir(), "bundle.zip")
+	out, err := os.Create(bundleFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := archiveZip.NewWriter(out)
+	for name, content := range files {
+		entry, errEntry := writer.Create(name)
+		if errEntry != nil {
+			t.Fatal(errEntry)
+		}
+		if _, errWrite := entry.Write([]byte(content)); errWrite != nil {
+			t.Fatal(errWrite)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := out.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bundleFile
+}
+
+func bundleJson(t *testing.T, manifest BundleManifest) string {
+	t.Helper()
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestReadBundleManifest(t *testing.T) {
+	valid := bundleJson(t, BundleManifest{
+		Version:   bundleVersion,
+		Platform:  "linux_x64",
+		Resources: []BundleResource{{Installed: resource.Installed{Key: "yt-dlp"}}},
+	})
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{name: "valid", files: map[string]string{BundleManifestName: valid, "yt-dlp/yt-dlp": "x"}},
+		{name: "parent dir", files: map[string]string{BundleManifestName: valid, "../evil": "x"}, wantErr: "outside the bundle"},
+		{name: "parent dir after a folder", files: map[string]string{BundleManifestName: valid, "yt-dlp/../../evil": "x"}, wantErr: "outside the bundle"},
+		{name: "backslashes", files: map[string]string{BundleManifestName: valid, `..\evil`: "x"}, wantErr: "outside the bundle"},
+		{name: "absolute path", files: map[string]string{BundleManifestName: valid, "/etc/evil": "x"}, wantErr: "outside the bundle"},
+		{name: "drive letter", files: map[string]string{BundleManifestName: valid, "C:/evil": "x"}, wantErr: "outside the bundle"},
+		{name: "no manifest", files: map[string]string{"yt-dlp/yt-dlp": "x"}, wantErr: "not a resource bundle"},
+		{name: "broken manifest", files: map[string]string{BundleManifestName: "{"}, wantErr: "read bundle.json"},
+		{name: "newer version", files: map[string]string{BundleManifestName: bundleJson(t, BundleManifest{Version: bundleVersion + 1})}, wantErr: "not supported"},
+		{name: "no resource", files: map[string]string{BundleManifestName: bundleJson(t, BundleManifest{Version: bundleVersion})}, wantErr: "holds no resource"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := readBundleManifest(writeBundle(t, test.files))
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("readBundleManifest() = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("readBundleManifest() = %v, want an error about %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckBundleResource(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake yt-dlp is a shell script")
+	}
+	script := "#!/bin/sh\necho 2024.05.27\n"
+	sum := sha256.Sum256([]byte(script))
+	digest := hex.EncodeToString(sum[:])
+	entry := func(files map[string]string) BundleResource {
+		return BundleResource{
+			Installed: resource.Installed{Key: resource.YtDlp.Key, Executables: map[string]string{"yt-dlp": "yt-dlp"}},
+			Files:     files,
+		}
+	}
+	tests := []struct {
+		name    string
+		extra   map[string]string
+		entry   BundleResource
+		wantErr string
+	}{
+		{name: "matching", entry: entry(map[string]string{"yt-dlp": digest})},
+		{name: "tampered", entry: entry(map[string]string{"yt-dlp": strings.Repeat("0", 64)}), wantErr: "failed the checksum check"},
+		{name: "missing file", entry: entry(map[string]string{"yt-dlp": digest, "lib/x.so": digest}), wantErr: "missing from the bundle"},
+		{name: "unlisted file", extra: map[string]string{"evil.sh": "x"}, entry: entry(map[string]string{"yt-dlp": digest}), wantErr: "not listed"},
+		{name: "no executable", entry: BundleResource{Installed: resource.Installed{Key: resource.YtDlp.Key}, Files: map[string]string{"yt-dlp": digest}}, wantErr: "does not name"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stage := t.TempDir()
+			dir := filepath.Join(stage, resource.YtDlp.Key)
+			files := map[string]string{"yt-dlp": script}
+			for name, content := range test.extra {
+				files[name] = content
+			}
+			for name, content := range files {
+				if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err := checkBundleResource(stage, test.entry)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("checkBundleResource() = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("checkBundleResource() = %v, want an error about %q", err, test.wantErr)
+			}
+		})
+	}
+}
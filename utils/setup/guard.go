@@ -0,0 +1,30 @@
+package setup
+
+import (
+	"fmt"
+	"sync"
+	"ytdlp/utils/resource"
+)
+
+// inFlight keeps installs, updates, rollbacks and bundle imports of one
+// resource from running at the same time, the later caller is refused
+// instead of waiting to redo the same work.
+var (
+	inFlightMu sync.Mutex
+	inFlight   = map[string]bool{}
+)
+
+// claim marks r as being worked on, the returned func releases it.
+func claim(r resource.Resource) (func(), error) {
+	inFlightMu.Lock()
+	defer inFlightMu.Unlock()
+	if inFlight[r.Key] {
+		return nil, fmt.Errorf("%s is already being installed or updated", r.Title)
+	}
+	inFlight[r.Key] = true
+	return func() {
+		inFlightMu.Lock()
+		delete(inFlight, r.Key)
+		inFlightMu.Unlock()
+	}, nil
+}
@@ -0,0 +1,28 @@
+package setup
+
+import (
+	"testing"
+	"ytdlp/utils/resource"
+)
+
+func TestClaim(t *testing.T) {
+	unclaim, err := claim(resource.YtDlp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, errAgain := claim(resource.YtDlp); errAgain == nil {
+		t.Error("second claim of yt-dlp succeeded while the first is held")
+	}
+	unclaimOther, errOther := claim(resource.FFmpeg)
+	if errOther != nil {
+		t.Errorf("claim of ffmpeg = %v, resources are guarded one by one", errOther)
+	} else {
+		unclaimOther()
+	}
+	unclaim()
+	unclaimAgain, errReleased := claim(resource.YtDlp)
+	if errReleased != nil {
+		t.Fatalf("claim after release = %v", errReleased)
+	}
+	unclaimAgain()
+}
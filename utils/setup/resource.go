@@ -0,0 +1,179 @@
+package setup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+	"ytdlp/helpers/logrus"
+	"ytdlp/utils"
+	"ytdlp/utils/emit"
+	"ytdlp/utils/resource"
+	"ytdlp/utils/settings"
+	"ytdlp/utils/zip"
+)
+
+// installResource downloads the build of r for this platform unless one of
+// its sources already resolves to a working binary. The mirrors are tried in
+// order, a failed, corrupt or slow download moves on to the next one.
+func installResource(ctx *context.Context, r resource.Resource) (ResultStatus, error) {
+	resolution := r.Resolve()
+	if resolution.Source != resource.SourceMissing {
+		logrus.LogrusLoggerWithContext(ctx).Infof("%s found at %s (%s)", r.Title, resolution.Path, resolution.Source)
+		return ResultSkipped, nil
+	}
+	if !r.Allows(resource.SourceManaged) {
+		return ResultFailed, fmt.Errorf("no working %s was found (%s) and managed copies are turned off in the settings", r.Title, resolution.Reasons())
+	}
+	// a managed copy that does not start is corrupt or half extracted
+	if path := r.ManagedPath(); path != "" {
+		logrus.LogrusLoggerWithContext(ctx).Warnf("%s at %s does not run, installing it again", r.Title, path)
+	}
+	candidates, errCandidates := r.Candidates()
+	if errCandidates != nil {
+		return ResultFailed, errCandidates
+	}
+	// a built-in build without a checksum is not a failure of the setup, a
+	// custom url without one is
+	if len(candidates) > 0 && !r.Custom() {
+		if err := checkVerifiable(r, candidates[0]); err != nil {
+			logrus.LogrusLoggerWithContext(ctx).Warn(err.Error())
+			return ResultUnavailable, fmt.Errorf("no verifiable %s build exists for %s, install it on the system path or allow unverified downloads in the settings", r.Title, utils.GetPlatform())
+		}
+	}
+	emitResource := emit.NewEmitResource(ctx, r.Key, r.Title)
+	emitResource.Start()
+	failures := make([]string, 0)
+	var errLast error
+	for i, artifact := range candidates {
+		last := i == len(candidates)-1
+		filePath, sha256, err := fetchArtifact(ctx, emitResource, r, artifact, last)
+		if err == nil {
+			defer func() {
+				_ = utils.CheckOrDeleteFile(filePath)
+			}()
+			if errUnpack := unpackResource(ctx, emitResource, r, artifact, filePath, sha256); errUnpack != nil {
+				emitResource.Error(errUnpack.Error())
+				return ResultFailed, errUnpack
+			}
+			return ResultInstalled, nil
+		}
+		if (*ctx).Err() != nil {
+			return ResultFailed, err
+		}
+		errLast = err
+		failures = append(failures, fmt.Sprintf("%s: %s", artifact.Mirror, err.Error()))
+		if !last {
+			logrus.LogrusLoggerWithContext(ctx).Warnf("%s mirror %s failed: %s, trying %s", r.Title, artifact.Mirror, err.Error(), candidates[i+1].Mirror)
+		}
+	}
+	err := errLast
+	if len(failures) > 1 {
+		err = fmt.Errorf("%s failed on every mirror: %s", r.Title, strings.Join(failures, "; "))
+	}
+	emitResource.Error(err.Error())
+	return ResultFailed, err
+}
+
+// fetchArtifact brings the download of one mirror into the download dir and
+// verifies it, it returns the file and its digest.
+func fetchArtifact(ctx *context.Context, emitResource emit.EmitResource, r resource.Resource, artifact resource.Artifact, last bool) (string, string, error) {
+	if err := checkVerifiable(r, artifact); err != nil {
+		return "", "", err
+	}
+	filePath := filepath.Join(utils.GetDownloadDir(), fmt.Sprintf("%s-%s", r.Key, artifact.FileName()))
+	if err := utils.CheckOrDeleteFile(filePath); err != nil {
+		return "", "", err
+	}
+	if local := resource.LocalPath(artifact.Url); local != "" {
+		emitResource.Progress("Copying", 0)
+		if err := utils.CopyFile(local, filePath); err != nil {
+			return "", "", err
+		}
+	} else {
+		// the last mirror is waited for however slow it is
+		minSpeed := float64(settings.Current().Resources.MinSpeed)
+		if last {
+			minSpeed = 0
+		}
+		if err := downloadFile(ctx, emitResource, artifact.Url, filePath, minSpeed); err != nil {
+			return "", "", err
+		}
+	}
+	emitResource.Progress("Verifying", 0)
+	sha256, errVerify := verify(ctx, r, artifact, filePath)
+	if errVerify != nil {
+		_ = utils.CheckOrDeleteFile(filePath)
+		return "", "", errVerify
+	}
+	return filePath, sha256, nil
+}
+
+func unpackResource(ctx *context.Context, emitResource emit.EmitResource, r resource.Resource, artifact resource.Artifact, filePath string, sha256 string) error {
+	if err := resource.ForgetInstalled(r.Key); err != nil {
+		logrus.LogrusLoggerWithContext(ctx).Warnf("forget %s: %s", r.Title, err.Error())
+	}
+	if err := utils.CheckOrDeleteDir(r.Dir()); err != nil {
+		return err
+	}
+	if err := utils.CheckOrCreateDir(r.Dir()); err != nil {
+		return err
+	}
+	if err := extract(ctx, emitResource, artifact, filePath, r); err != nil {
+		return err
+	}
+	executables := r.Discover()
+	if _, ok := executables[r.Executable]; !ok {
+		return fmt.Errorf("%s download does not contain %s", r.Title, r.FileName())
+	}
+	if runtime.GOOS != "windows" {
+		for _, rel := range executables {
+			if err := os.Chmod(filepath.Join(r.Dir(), filepath.FromSlash(rel)), 0755); err != nil {
+				return err
+			}
+		}
+	}
+	binary := filepath.Join(r.Dir(), filepath.FromSlash(executables[r.Executable]))
+	version, errVersion := r.Version(binary)
+	if errVersion != nil {
+		logrus.LogrusLoggerWithContext(ctx).Warnf("%s version: %s", r.Title, errVersion.Error())
+	}
+	if err := resource.RecordInstalled(resource.Installed{
+		Key:         r.Key,
+		Version:     version,
+		Url:         artifact.Url,
+		Mirror:      artifact.Mirror,
+		Sha256:      sha256,
+		InstalledAt: time.Now(),
+		Executables: executables,
+	}); err != nil {
+		return err
+	}
+	emitResource.Stop()
+	logrus.LogrusLoggerWithContext(ctx).Infof("%s installed at %s from %s", r.Title, binary, artifact.Mirror)
+	return nil
+}
+
+func extract(ctx *context.Context, emitResource emit.EmitResource, artifact resource.Artifact, filePath string, r resource.Resource) error {
+	if artifact.Archive == "" {
+		binary := artifact.Binary
+		if binary == "" {
+			binary = r.FileName()
+		}
+		return os.Rename(filePath, filepath.Join(r.Dir(), filepath.FromSlash(binary)))
+	}
+	emitResource.Progress("Extracting", 0)
+	switch artifact.Archive {
+	case resource.ArchiveZip:
+		return zip.UnzipFile(ctx, filePath, r.Dir())
+	case resource.ArchiveTarGz:
+		return zip.UntarFile(ctx, filePath, r.Dir())
+	case resource.ArchiveTarXz:
+		return zip.UntarXzCmd(filePath, r.Dir())
+	default:
+		return fmt.Errorf("unsupported archive %q", strings.ToLower(artifact.Archive))
+	}
+}
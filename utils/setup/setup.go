@@ -2,11 +2,79 @@ package setup
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
+	"strings"
 	"sync"
 	"ytdlp/utils"
+	"ytdlp/utils/emit"
+	"ytdlp/utils/resource"
 )
 
+type ResultStatus string
+
+const (
+	ResultInstalled ResultStatus = "installed"
+	// ResultSkipped means a working copy was already there
+	ResultSkipped ResultStatus = "skipped"
+	// ResultUnavailable means no build for this platform can be verified,
+	// the user installs one on the system path
+	ResultUnavailable ResultStatus = "unavailable"
+	ResultFailed      ResultStatus = "failed"
+)
+
+type Result struct {
+	Key        string              `json:"key"`
+	Title      string              `json:"title"`
+	Status     ResultStatus        `json:"status"`
+	Error      string              `json:"error"`
+	Resolution resource.Resolution `json:"resolution"`
+}
+
+// Summary holds one result per installed resource in the order of
+// resource.List, Ready tells whether every resource resolves afterwards,
+// leaving out the unavailable ones.
+type Summary struct {
+	Results []Result `json:"results"`
+	Ready   bool     `json:"ready"`
+}
+
+func (s Summary) Failed() []Result {
+	failed := make([]Result, 0)
+	for _, result := range s.Results {
+		if result.Status == ResultFailed {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+func (s Summary) String() string {
+	parts := make([]string, 0, len(s.Results))
+	for _, result := range s.Results {
+		part := fmt.Sprintf("%s %s", result.Title, result.Status)
+		if result.Error != "" {
+			part = fmt.Sprintf("%s: %s", part, result.Error)
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Err joins the errors of the failed resources, nil when all are ready.
+func (s Summary) Err() error {
+	failed := s.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	errs := make([]error, 0, len(failed))
+	for _, result := range failed {
+		errs = append(errs, fmt.Errorf("%s: %s", result.Title, result.Error))
+	}
+	return errors.Join(errs...)
+}
+
 type Setup struct {
 	ctx *context.Context
 }
@@ -17,37 +85,70 @@ func NewSetup(ctx *context.Context) *Setup {
 	}
 }
 
-func (s *Setup) Install() error {
+// Install sets up every resource in parallel and emits the summary, a
+// failure of one does not stop the others.
+func (s *Setup) Install() (Summary, error) {
+	return s.install(resource.List())
+}
+
+// InstallResource sets up one resource again, e.g. after it failed.
+func (s *Setup) InstallResource(key string) (Summary, error) {
+	r, err := resource.Get(key)
+	if err != nil {
+		return Summary{}, err
+	}
+	return s.install([]resource.Resource{r})
+}
+
+func (s *Setup) install(resources []resource.Resource) (Summary, error) {
 	if errInitFolder := s.initFolder(); errInitFolder != nil {
-		return errInitFolder
+		return Summary{}, errInitFolder
 	}
-	errChan := make(chan error, 1)
-	defer close(errChan)
+	results := make([]Result, len(resources))
 	var wg sync.WaitGroup
-	wg.Add(2)
-
-	go func() {
-		defer wg.Done()
-		ffmpeg := NewFFmpeg(s.ctx)
-		if errSetup := ffmpeg.Setup(); errSetup != nil {
-			errChan <- errSetup
-		}
-	}()
-	go func() {
-		defer wg.Done()
-		ytdlp := NewYtDlp(s.ctx)
-		if errSetup := ytdlp.Setup(); errSetup != nil {
-			errChan <- errSetup
+	for i, r := range resources {
+		wg.Add(1)
+		go func(i int, r resource.Resource) {
+			defer wg.Done()
+			results[i] = s.installOne(r)
+		}(i, r)
+	}
+	wg.Wait()
+	summary := Summary{Results: results, Ready: true}
+	unavailable := map[string]bool{}
+	for _, result := range results {
+		unavailable[result.Key] = result.Status == ResultUnavailable
+	}
+	// a retry of one resource installs only that one, the others count too
+	for _, resolution := range resource.ResolveAll() {
+		if resolution.Source == resource.SourceMissing && !unavailable[resolution.Key] {
+			summary.Ready = false
 		}
-	}()
+	}
+	emit.Emit(s.ctx, emit.ResourceSummary, summary)
+	return summary, summary.Err()
+}
 
-	wg.Wait()
-	select {
-	case err := <-errChan:
-		return err
-	default:
-		return nil
+func (s *Setup) installOne(r resource.Resource) Result {
+	result := Result{Key: r.Key, Title: r.Title}
+	unclaim, errClaim := claim(r)
+	if errClaim != nil {
+		result.Status = ResultFailed
+		result.Error = errClaim.Error()
+		result.Resolution = r.Resolve()
+		return result
+	}
+	defer unclaim()
+	status, err := installResource(s.ctx, r)
+	result.Status = status
+	if err != nil {
+		if status != ResultUnavailable {
+			result.Status = ResultFailed
+		}
+		result.Error = err.Error()
 	}
+	result.Resolution = r.Resolve()
+	return result
 }
 
 func (s *Setup) initFolder() error {
@@ -0,0 +1,349 @@
+package setup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+	"ytdlp/helpers/logrus"
+	"ytdlp/utils"
+	"ytdlp/utils/emit"
+	"ytdlp/utils/resource"
+	"ytdlp/utils/settings"
+)
+
+const (
+	// kept next to the binary so an update that breaks extraction can be undone
+	previousSuffix = ".previous"
+	updateSuffix   = ".update"
+)
+
+// releaseRepos lists the GitHub repositories each updatable resource is
+// released from, by channel. Pinned tags come from the stable repository.
+var releaseRepos = map[string]map[string]string{
+	resource.YtDlp.Key: {
+		settings.UpdateChannelStable:  "yt-dlp/yt-dlp",
+		settings.UpdateChannelNightly: "yt-dlp/yt-dlp-nightly-builds",
+	},
+}
+
+type UpdateInfo struct {
+	Key     string          `json:"key"`
+	Title   string          `json:"title"`
+	Channel string          `json:"channel"`
+	Source  resource.Source `json:"source"`
+	// Current is empty when the resource is missing or does not run
+	Current   string `json:"current"`
+	Latest    string `json:"latest"`
+	Available bool   `json:"available"`
+	// CanRollback tells whether the version before the last update is kept
+	CanRollback bool `json:"canRollback"`
+	// Error tells why the latest release could not be looked up
+	Error string `json:"error,omitempty"`
+}
+
+type releaseStruct struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name string `json:"name"`
+		Url  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+type release struct {
+	version  string
+	artifact resource.Artifact
+}
+
+func updatable(key string) (resource.Resource, error) {
+	r, err := resource.Get(key)
+	if err != nil {
+		return resource.Resource{}, err
+	}
+	if _, ok := releaseRepos[key]; !ok {
+		return resource.Resource{}, fmt.Errorf("%s has no update channel", r.Title)
+	}
+	return r, nil
+}
+
+// CheckUpdates checks every updatable resource, one that cannot be checked
+// keeps its current version and the reason in Error.
+func CheckUpdates(ctx *context.Context) []UpdateInfo {
+	infos := make([]UpdateInfo, 0)
+	for _, r := range resource.List() {
+		if _, ok := releaseRepos[r.Key]; !ok {
+			continue
+		}
+		info, err := CheckUpdate(ctx, r.Key)
+		if err != nil {
+			info.Key, info.Title = r.Key, r.Title
+			info.Error = err.Error()
+			logrus.LogrusLoggerWithContext(ctx).Warnf("Check %s update: %s", r.Title, info.Error)
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// CheckUpdate compares the installed version with the latest release of
+// the configured channel.
+func CheckUpdate(ctx *context.Context, key string) (UpdateInfo, error) {
+	r, err := updatable(key)
+	if err != nil {
+		return UpdateInfo{}, err
+	}
+	info := currentInfo(r)
+	latest, err := latestRelease(ctx, r)
+	if err != nil {
+		return info, err
+	}
+	info.Latest = latest.version
+	info.Available = info.Current != latest.version
+	return info, nil
+}
+
+// Update installs the latest release of the channel as the managed copy, the
+// replaced binary is kept for Rollback. A system or custom copy is left
+// alone, the managed one is only run when it comes first in the order.
+func Update(ctx *context.Context, key string) (UpdateInfo, error) {
+	r, err := updatable(key)
+	if err != nil {
+		return UpdateInfo{}, err
+	}
+	if !r.Allows(resource.SourceManaged) {
+		return currentInfo(r), fmt.Errorf("managed copies of %s are turned off in the settings", r.Title)
+	}
+	unclaim, errClaim := claim(r)
+	if errClaim != nil {
+		return currentInfo(r), errClaim
+	}
+	defer unclaim()
+	latest, err := latestRelease(ctx, r)
+	if err != nil {
+		return currentInfo(r), err
+	}
+	if info := currentInfo(r); info.Source == resource.SourceManaged && info.Current == latest.version {
+		info.Latest = latest.version
+		return info, nil
+	}
+	emitResource := emit.NewEmitResource(ctx, r.Key, r.Title)
+	emitResource.Start()
+	// every failure from here on ends the progress the window shows
+	fail := func(err error) (UpdateInfo, error) {
+		emitResource.Error(err.Error())
+		return currentInfo(r), err
+	}
+	if err := utils.CheckOrCreateDir(r.Dir()); err != nil {
+		return fail(err)
+	}
+	binary := filepath.Join(r.Dir(), r.FileName())
+	updatePath := binary + updateSuffix
+	if err := utils.CheckOrDeleteFile(updatePath); err != nil {
+		return fail(err)
+	}
+	if err := DownloadFile(ctx, emitResource, latest.artifact.Url, updatePath); err != nil {
+		_ = utils.CheckOrDeleteFile(updatePath)
+		return fail(err)
+	}
+	emitResource.Progress("Verifying", 0)
+	sha256, errVerify := verify(ctx, r, latest.artifact, updatePath)
+	if errVerify != nil {
+		_ = utils.CheckOrDeleteFile(updatePath)
+		return fail(errVerify)
+	}
+	if runtime.GOOS != "windows" {
+		if err := os.Chmod(updatePath, 0755); err != nil {
+			_ = utils.CheckOrDeleteFile(updatePath)
+			return fail(err)
+		}
+	}
+	version, errVersion := r.Version(updatePath)
+	if errVersion != nil {
+		_ = utils.CheckOrDeleteFile(updatePath)
+		return fail(fmt.Errorf("%s %s does not run: %s", r.Title, latest.version, errVersion.Error()))
+	} else if version != latest.version {
+		logrus.LogrusLoggerWithContext(ctx).Warnf("%s release %s reports version %s", r.Title, latest.version, version)
+	}
+	previous, hadPrevious := r.Installed()
+	if err := swap(binary, updatePath); err != nil {
+		_ = utils.CheckOrDeleteFile(updatePath)
+		return fail(err)
+	}
+	entry := resource.Installed{
+		Key:         r.Key,
+		Version:     version,
+		Url:         latest.artifact.Url,
+		Mirror:      settings.Current().Updates.Endpoint,
+		Sha256:      sha256,
+		InstalledAt: time.Now(),
+		Executables: map[string]string{r.Executable: r.FileName()},
+	}
+	if hadPrevious {
+		previous.Previous = nil
+		entry.Previous = &previous
+	}
+	if err := resource.RecordInstalled(entry); err != nil {
+		logrus.LogrusLoggerWithContext(ctx).Warnf("record %s: %s", r.Title, err.Error())
+	}
+	emitResource.Stop()
+	logrus.LogrusLoggerWithContext(ctx).Infof("%s updated to %s", r.Title, latest.version)
+	info := currentInfo(r)
+	if info.Source != resource.SourceManaged {
+		logrus.LogrusLoggerWithContext(ctx).Warnf("%s resolves to the %s copy first, the update is used once that one is gone", r.Title, info.Source)
+	}
+	info.Latest = latest.version
+	return info, nil
+}
+
+// Rollback puts the binary replaced by the last update back.
+func Rollback(ctx *context.Context, key string) (UpdateInfo, error) {
+	r, err := updatable(key)
+	if err != nil {
+		return UpdateInfo{}, err
+	}
+	unclaim, errClaim := claim(r)
+	if errClaim != nil {
+		return currentInfo(r), errClaim
+	}
+	defer unclaim()
+	binary := filepath.Join(r.Dir(), r.FileName())
+	previous := binary + previousSuffix
+	if _, errStat := os.Stat(previous); errStat != nil {
+		return currentInfo(r), fmt.Errorf("no previous %s version to roll back to", r.Title)
+	}
+	// the two binaries trade places so the rollback can be undone as well
+	if err := swap(binary, previous); err != nil {
+		return currentInfo(r), err
+	}
+	if entry, ok := r.Installed(); ok && entry.Previous != nil {
+		restored := *entry.Previous
+		entry.Previous = nil
+		restored.Previous = &entry
+		// the swapped binary sits at the top of the dir now
+		restored.Executables = map[string]string{r.Executable: r.FileName()}
+		if err := resource.RecordInstalled(restored); err != nil {
+			logrus.LogrusLoggerWithContext(ctx).Warnf("record %s: %s", r.Title, err.Error())
+		}
+	} else {
+		_ = resource.ForgetInstalled(r.Key)
+	}
+	info := currentInfo(r)
+	logrus.LogrusLoggerWithContext(ctx).Infof("%s rolled back to %s", r.Title, info.Current)
+	return info, nil
+}
+
+// swap moves replacement over binary and keeps the old binary as the
+// previous version.
+func swap(binary string, replacement string) error {
+	previous := binary + previousSuffix
+	stash := binary + ".swap"
+	if err := utils.CheckOrDeleteFile(stash); err != nil {
+		return err
+	}
+	hadBinary := false
+	if _, err := os.Stat(binary); err == nil {
+		if errMove := os.Rename(binary, stash); errMove != nil {
+			return errMove
+		}
+		hadBinary = true
+	}
+	if err := os.Rename(replacement, binary); err != nil {
+		if hadBinary {
+			_ = os.Rename(stash, binary)
+		}
+		return err
+	}
+	if !hadBinary {
+		return nil
+	}
+	return os.Rename(stash, previous)
+}
+
+func currentInfo(r resource.Resource) UpdateInfo {
+	current := settings.Current().Updates
+	resolution := r.Resolve()
+	info := UpdateInfo{
+		Key:     r.Key,
+		Title:   r.Title,
+		Channel: current.Channel,
+		Source:  resolution.Source,
+	}
+	if resolution.Path != "" {
+		info.Current, _ = r.Version(resolution.Path)
+	}
+	if _, err := os.Stat(filepath.Join(r.Dir(), r.FileName()) + previousSuffix); err == nil {
+		info.CanRollback = true
+	}
+	return info
+}
+
+// latestRelease asks the release feed for the newest release of the channel
+// and picks the asset built for this platform.
+func latestRelease(ctx *context.Context, r resource.Resource) (release, error) {
+	current := settings.Current().Updates
+	repos := releaseRepos[r.Key]
+	repo, ok := repos[current.Channel]
+	endpoint := "releases/latest"
+	if current.Channel == settings.UpdateChannelPinned {
+		tag := strings.TrimSpace(current.PinnedVersion)
+		if tag == "" {
+			return release{}, errors.New("the pinned update channel needs a version")
+		}
+		repo, ok = repos[settings.UpdateChannelStable]
+		endpoint = "releases/tags/" + url.PathEscape(tag)
+	}
+	if !ok {
+		return release{}, fmt.Errorf("%s has no %s channel", r.Title, current.Channel)
+	}
+	feedUrl := fmt.Sprintf("%s/repos/%s/%s", strings.TrimRight(current.Endpoint, "/"), repo, endpoint)
+	reqCtx, cancel := context.WithTimeout(*ctx, 30*time.Second)
+	defer cancel()
+	req, errReq := http.NewRequestWithContext(reqCtx, http.MethodGet, feedUrl, nil)
+	if errReq != nil {
+		return release{}, errReq
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, errResp := http.DefaultClient.Do(req)
+	if errResp != nil {
+		return release{}, fmt.Errorf("check %s releases: %s", r.Title, errResp.Error())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return release{}, fmt.Errorf("check %s releases: %s", r.Title, resp.Status)
+	}
+	var feed releaseStruct
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 8<<20)).Decode(&feed); err != nil {
+		return release{}, fmt.Errorf("read %s releases: %s", r.Title, err.Error())
+	}
+	// the manifest artifact names the asset built for this platform
+	artifact, ok := r.Artifacts[utils.GetPlatform()]
+	if !ok {
+		return release{}, fmt.Errorf("%s has no build for %s", r.Title, utils.GetPlatform())
+	}
+	assetName := path.Base(artifact.Url)
+	latest := release{version: feed.TagName}
+	for _, asset := range feed.Assets {
+		switch asset.Name {
+		case assetName:
+			latest.artifact.Url = asset.Url
+		case path.Base(artifact.SumsUrl):
+			latest.artifact.SumsUrl = asset.Url
+		}
+	}
+	if latest.version == "" || latest.artifact.Url == "" {
+		return release{}, fmt.Errorf("%s release %q has no %s", r.Title, feed.TagName, assetName)
+	}
+	if latest.artifact.SumsUrl == "" {
+		return release{}, errors.New("release has no checksum file, refusing to install it unverified")
+	}
+	return latest, nil
+}
@@ -0,0 +1,144 @@
+package setup
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"ytdlp/helpers/logrus"
+	"ytdlp/utils"
+	"ytdlp/utils/resource"
+	"ytdlp/utils/settings"
+)
+
+func GetQuarantineDir() string {
+	return filepath.Join(utils.GetDownloadDir(), "quarantine")
+}
+
+// expectedSha256 returns the digest the download must have, looked up in
+// the upstream checksum list when the manifest only points at it. An empty
+// result means the artifact carries no checksum.
+func expectedSha256(ctx *context.Context, artifact resource.Artifact) (string, error) {
+	if artifact.Sha256 != "" {
+		return strings.ToLower(artifact.Sha256), nil
+	}
+	if artifact.SumsUrl == "" {
+		return "", nil
+	}
+	sums, errSums := openSums(ctx, artifact.SumsUrl)
+	if errSums != nil {
+		return "", errSums
+	}
+	defer sums.Close()
+	name := artifact.FileName()
+	scanner := bufio.NewScanner(io.LimitReader(sums, 1<<20))
+	for scanner.Scan() {
+		// "<digest>  <name>", a leading * marks binary mode
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("read checksums: %s", err.Error())
+	}
+	return "", fmt.Errorf("%s is not listed in %s", name, artifact.SumsUrl)
+}
+
+// openSums fetches the upstream checksum list.
+func openSums(ctx *context.Context, sumsUrl string) (io.ReadCloser, error) {
+	reqCtx, cancel := context.WithTimeout(*ctx, time.Minute)
+	req, errReq := http.NewRequestWithContext(reqCtx, http.MethodGet, sumsUrl, nil)
+	if errReq != nil {
+		cancel()
+		return nil, errReq
+	}
+	resp, errResp := http.DefaultClient.Do(req)
+	if errResp != nil {
+		cancel()
+		return nil, fmt.Errorf("fetch checksums: %s", errResp.Error())
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("fetch checksums: %s", resp.Status)
+	}
+	return &cancelReadCloser{ReadCloser: resp.Body, cancel: cancel}, nil
+}
+
+// cancelReadCloser releases the request context with the body.
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelReadCloser) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
+func fileSha256(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// checkVerifiable refuses an artifact without a checksum unless the settings
+// allow unverified downloads, so nothing is fetched just to be thrown away.
+func checkVerifiable(r resource.Resource, artifact resource.Artifact) error {
+	if artifact.Sha256 != "" || artifact.SumsUrl != "" || settings.Current().Resources.AllowUnverified {
+		return nil
+	}
+	return fmt.Errorf("%s from %s has no checksum, set its sha256 or allow unverified downloads in the settings", r.Title, artifact.Url)
+}
+
+// verify checks the download before anything is extracted or run and
+// returns its digest, empty when unverified. A download without a checksum
+// is refused unless the settings allow it, a file that does not match is
+// moved to the quarantine dir.
+func verify(ctx *context.Context, r resource.Resource, artifact resource.Artifact, filePath string) (string, error) {
+	expected, errExpected := expectedSha256(ctx, artifact)
+	if errExpected != nil {
+		return "", fmt.Errorf("%s cannot be verified: %s", r.Title, errExpected.Error())
+	}
+	if expected == "" {
+		if err := checkVerifiable(r, artifact); err != nil {
+			return "", err
+		}
+		logrus.LogrusLoggerWithContext(ctx).Warnf("%s from %s has no checksum, installing it unverified as allowed by the settings", r.Title, artifact.Url)
+		return "", nil
+	}
+	actual, errHash := fileSha256(filePath)
+	if errHash != nil {
+		return "", errHash
+	}
+	if actual == expected {
+		logrus.LogrusLoggerWithContext(ctx).Infof("%s checksum verified", r.Title)
+		return actual, nil
+	}
+	quarantined := filepath.Join(GetQuarantineDir(), fmt.Sprintf("%s.%s", filepath.Base(filePath), time.Now().Format("20060102150405")))
+	if errDir := utils.CheckOrCreateDir(GetQuarantineDir()); errDir != nil {
+		return "", errDir
+	}
+	if errMove := os.Rename(filePath, quarantined); errMove != nil {
+		_ = os.Remove(filePath)
+		return "", fmt.Errorf("%s download failed the checksum check (expected %s, got %s), the file was deleted",
+			r.Title, expected, actual)
+	}
+	return "", fmt.Errorf("%s download failed the checksum check (expected %s, got %s), the file was moved to %s",
+		r.Title, expected, actual, quarantined)
+}
@@ -9,6 +9,7 @@ import (
 	"log"
 	"math"
 	"math/rand"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -70,11 +71,6 @@ func GetCwd() string {
 	return currentDir
 }
 
-func GetHomeDir() string {
-	userHomeDir, _ := os.UserHomeDir()
-	return filepath.Join(userHomeDir, ".ytdlp")
-}
-
 func GetResourceDir() string {
 	homeDir := GetHomeDir()
 	return filepath.Join(homeDir, "resources")
@@ -90,25 +86,19 @@ func GetDownloadDir() string {
 	return filepath.Join(homeDir, "download")
 }
 
-func GetExtensionDir() string {
-	homeDir := GetHomeDir()
-	return filepath.Join(homeDir, "extensions")
+func GetOutputDir() string {
+	userHomeDir, _ := os.UserHomeDir()
+	return filepath.Join(userHomeDir, "Downloads", "ytdlp")
 }
 
-func GetFFmpegPath() string {
-	resourceDir := GetResourceDir()
-	if runtime.GOOS == "windows" {
-		return filepath.Join(resourceDir, "ffmpeg", "ffmpeg-N-116752-g507c2a5774-win64-gpl", "bin", "ffmpeg.exe")
-	}
-	return "ffmpeg"
+func GetCacheDir() string {
+	homeDir := GetHomeDir()
+	return filepath.Join(homeDir, "cache")
 }
 
-func GetYtDlpPath() string {
-	resourceDir := GetResourceDir()
-	if runtime.GOOS == "windows" {
-		return filepath.Join(resourceDir, "yt-dlp", "yt-dlp.exe")
-	}
-	return "youtube-dl"
+func GetExtensionDir() string {
+	homeDir := GetHomeDir()
+	return filepath.Join(homeDir, "extensions")
 }
 
 func getCurrentVersion() (string, error) {
@@ -141,21 +131,13 @@ func getCurrentVersion() (string, error) {
 	return versions[len(versions)-1], nil
 }
 
+// GetPlatform names the os and cpu, e.g. windows_x64 or darwin_arm64.
 func GetPlatform() string {
-	switch platform := runtime.GOOS; platform {
-	case "darwin":
-		if runtime.GOARCH == "arm64" {
-			return "darwin_x64"
-		} else {
-			return "darwin_x64"
-		}
-	case "linux":
-		return "linux"
-	case "windows":
-		return "windows"
-	default:
-		return "linux"
+	arch := runtime.GOARCH
+	if arch == "amd64" {
+		arch = "x64"
 	}
+	return fmt.Sprintf("%s_%s", runtime.GOOS, arch)
 }
 
 func CopyDir(src, dest string) error {
@@ -200,6 +182,10 @@ func CopyFile(src, dest string) error {
 	if errDestFile != nil {
 		return errDestFile
 	}
+	// keep executables runnable
+	if srcInfo, errStat := srcFile.Stat(); errStat == nil {
+		_ = destFile.Chmod(srcInfo.Mode().Perm())
+	}
 	defer func(destFile *os.File) {
 		if errDestFileClose := destFile.Close(); errDestFileClose != nil {
 			log.Println(errDestFileClose.Error())
@@ -211,6 +197,42 @@ func CopyFile(src, dest string) error {
 	return nil
 }
 
+// WriteFileAtomic writes to a temp file in the same folder and renames it over
+// pathFile, so readers never see a half-written file.
+func WriteFileAtomic(pathFile string, data []byte, perm os.FileMode) error {
+	if err := CheckOrCreateDir(filepath.Dir(pathFile)); err != nil {
+		return err
+	}
+	tmpFile, errTmp := os.CreateTemp(filepath.Dir(pathFile), filepath.Base(pathFile)+".*.tmp")
+	if errTmp != nil {
+		return errTmp
+	}
+	tmpPath := tmpFile.Name()
+	if _, errWrite := tmpFile.Write(data); errWrite != nil {
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpPath)
+		return errWrite
+	}
+	if errSync := tmpFile.Sync(); errSync != nil {
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpPath)
+		return errSync
+	}
+	if errClose := tmpFile.Close(); errClose != nil {
+		_ = os.Remove(tmpPath)
+		return errClose
+	}
+	if errChmod := os.Chmod(tmpPath, perm); errChmod != nil {
+		_ = os.Remove(tmpPath)
+		return errChmod
+	}
+	if errRename := os.Rename(tmpPath, pathFile); errRename != nil {
+		_ = os.Remove(tmpPath)
+		return errRename
+	}
+	return nil
+}
+
 type BoudingBox struct {
 	X      float64 `json:"x"`
 	Y      float64 `json:"y"`
@@ -400,6 +422,25 @@ func GetLocalIP() string {
 	return string(ip)
 }
 
+// GetLanIP returns the first private IPv4 address of this machine, falling
+// back to loopback when there is no network.
+func GetLanIP() string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return "127.0.0.1"
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLoopback() {
+			continue
+		}
+		if ip := ipNet.IP.To4(); ip != nil && ip.IsPrivate() {
+			return ip.String()
+		}
+	}
+	return "127.0.0.1"
+}
+
 func GenerateSessionID() string {
 	entropy := rand.New(rand.NewSource(time.Now().UnixNano()))
 	ms := ulid.Timestamp(time.Now())
@@ -462,6 +503,20 @@ func IsInRange(value, min, max float64) bool {
 	return value >= min && value <= max
 }
 
+func SanitizeFilename(name string) string {
+	name = strings.TrimSpace(name)
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`<>:"/\|?*`, r) || r < 32 {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" {
+		return "untitled"
+	}
+	return name
+}
+
 func UpperFirstLetter(s string) string {
 	if len(s) == 0 {
 		return ""
@@ -10,16 +10,16 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
-	"runtime"
 	"strings"
 	"ytdlp/helpers/logrus"
+	"ytdlp/utils"
 )
 
+// UnzipFile extracts in process on every platform, an entry that would land
+// outside destFolder fails the whole archive.
 func UnzipFile(ctx *context.Context, zipFile, destFolder string) error {
-	if runtime.GOOS != "windows" {
-		return UnzipCmd(zipFile, destFolder)
-	}
 	reader, errReader := zip.OpenReader(zipFile)
 	if errReader != nil {
 		return errReader
@@ -30,6 +30,11 @@ func UnzipFile(ctx *context.Context, zipFile, destFolder string) error {
 		}
 	}(reader)
 
+	for _, file := range reader.File {
+		if err := CheckEntryName(file.Name); err != nil {
+			return err
+		}
+	}
 	for _, file := range reader.File {
 		if errUnzip := unzip(ctx, file, destFolder); errUnzip != nil {
 			return errors.New(fmt.Sprintf("unzip file error: %s", errUnzip.Error()))
@@ -39,24 +44,43 @@ func UnzipFile(ctx *context.Context, zipFile, destFolder string) error {
 	return nil
 }
 
+// CheckEntryName rejects archive entries with an absolute path, a drive
+// letter or a way out through "..", with either kind of slash.
+func CheckEntryName(name string) error {
+	clean := strings.ReplaceAll(name, `\`, "/")
+	if path.IsAbs(clean) || strings.Contains(clean, ":") || path.Clean(clean) == ".." || strings.HasPrefix(path.Clean(clean), "../") {
+		return fmt.Errorf("archive entry %q is outside the archive", name)
+	}
+	return nil
+}
+
 func unzip(ctx *context.Context, file *zip.File, destFolder string) error {
-	filePath := filepath.Join(destFolder, file.Name)
-	if strings.HasPrefix(file.Name, "/") {
-		return fmt.Errorf("invalid file path: %s", file.Name)
+	filePath := filepath.Join(destFolder, filepath.FromSlash(strings.ReplaceAll(file.Name, `\`, "/")))
+	mode := file.Mode()
+	if mode.IsDir() {
+		return os.MkdirAll(filePath, os.ModePerm)
 	}
-	if file.FileInfo().IsDir() {
-		if errMkdir := os.MkdirAll(filePath, os.ModePerm); errMkdir != nil {
-			return errMkdir
-		}
+	if !mode.IsRegular() {
+		// links could point anywhere, the archives we install do not need them
+		logrus.LogrusLoggerWithContext(ctx).Warnf("Skipping %s, it is not a regular file", file.Name)
 		return nil
 	}
 	if errMkdir := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); errMkdir != nil {
 		return errMkdir
 	}
-	outFile, errOutFile := os.Create(filePath)
+	perm := mode.Perm()
+	if perm == 0 {
+		perm = 0644
+	}
+	outFile, errOutFile := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
 	if errOutFile != nil {
 		return errOutFile
 	}
+	defer func(outFile *os.File) {
+		if errRemove := outFile.Close(); errRemove != nil {
+			logrus.LogrusLoggerWithContext(ctx).Error(errRemove.Error())
+		}
+	}(outFile)
 	inFile, errInFile := file.Open()
 	if errInFile != nil {
 		return errInFile
@@ -69,11 +93,6 @@ func unzip(ctx *context.Context, file *zip.File, destFolder string) error {
 	if _, errCopy := io.Copy(outFile, inFile); errCopy != nil {
 		return errCopy
 	}
-	defer func(outFile *os.File) {
-		if errRemove := outFile.Close(); errRemove != nil {
-			logrus.LogrusLoggerWithContext(ctx).Error(errRemove.Error())
-		}
-	}(outFile)
 	return nil
 }
 
@@ -150,13 +169,12 @@ func UntarCmd(tarFile, destFolder string) error {
 	return nil
 }
 
-func UnzipCmd(zipFile, destFolder string) error {
-	cmd := fmt.Sprintf("unzip -o %s -d %s", zipFile, destFolder)
-	if err := exec.Command("sh", "-c", cmd).Run(); err != nil {
-		if strings.Contains(err.Error(), "exit status 1") {
-			return nil
-		}
-		return err
+// UntarXzCmd relies on the system tar, the standard library has no xz reader.
+func UntarXzCmd(tarFile, destFolder string) error {
+	cmd := exec.Command("tar", "-xJf", tarFile, "-C", destFolder)
+	utils.HideWindow(cmd)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("untar %s: %s", filepath.Base(tarFile), strings.TrimSpace(string(output)))
 	}
 	return nil
 }
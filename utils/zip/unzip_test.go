@@ -0,0 +1,84 @@
+package zip
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func writeZip(t *testing.T, zipFile string, files map[string]string) {
+	t.Helper()
+	out, err := os.Create(zipFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := zip.NewWriter(out)
+	for name, content := range files {
+		header := &zip.FileHeader{Name: name, Method: zip.Deflate}
+		header.SetMode(0755)
+		entry, errEntry := writer.CreateHeader(header)
+		if errEntry != nil {
+			t.Fatal(errEntry)
+		}
+		if _, errWrite := entry.Write([]byte(content)); errWrite != nil {
+			t.Fatal(errWrite)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := out.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnzipFile(t *testing.T) {
+	// names a shell would split or run
+	dir := filepath.Join(t.TempDir(), "My Downloads $(touch pwned)")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	zipFile := filepath.Join(dir, "ffmpeg 7.zip")
+	writeZip(t, zipFile, map[string]string{"ffmpeg": "binary", "doc/readme.txt": "hi"})
+	dest := filepath.Join(dir, "out dir")
+	ctx := context.Background()
+	if err := UnzipFile(&ctx, zipFile, dest); err != nil {
+		t.Fatalf("UnzipFile() = %v", err)
+	}
+	for name, want := range map[string]string{"ffmpeg": "binary", "doc/readme.txt": "hi"} {
+		got, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(name)))
+		if err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if runtime.GOOS != "windows" {
+		if info, err := os.Stat(filepath.Join(dest, "ffmpeg")); err != nil || info.Mode().Perm()&0100 == 0 {
+			t.Errorf("ffmpeg lost its executable bit: %v", err)
+		}
+	}
+	if _, err := os.Stat("pwned"); !os.IsNotExist(err) {
+		t.Error("the archive path was run by a shell")
+	}
+}
+
+func TestUnzipFileRejectsEscapes(t *testing.T) {
+	for _, name := range []string{"../evil", "a/../../evil", `..\evil`, "/etc/evil", "C:/evil"} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			zipFile := filepath.Join(dir, "bad.zip")
+			writeZip(t, zipFile, map[string]string{"good": "x", name: "x"})
+			dest := filepath.Join(dir, "out")
+			ctx := context.Background()
+			if err := UnzipFile(&ctx, zipFile, dest); err == nil || !strings.Contains(err.Error(), "outside the archive") {
+				t.Fatalf("UnzipFile() = %v, want an error about the entry", err)
+			}
+			if _, err := os.Stat(dest); !os.IsNotExist(err) {
+				t.Errorf("entries were extracted before the check: %v", err)
+			}
+		})
+	}
+}